@@ -1,17 +1,44 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
-	_ "testing"
+	"testing"
+	"time"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
 )
 
-func Example() {
-	// defer stdin close and establish workdir from args[1]
+func Test(test *testing.T) {
+	// deliver test pipeline file content as stdin to "check" the same as actual pipeline execution
+	os.Stdin, _ = util.FixtureFile("fixtures/token_kv.json")
 	defer os.Stdin.Close()
 
-	// deliver test pipeline file content as stdin to "in" the same as actual pipeline execution
-	os.Stdin, _ = os.OpenFile("fixtures/token_kv.json", os.O_RDONLY, 0o644)
+	// capture the check response from stdout
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		test.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
 
+	// invoke main
 	main()
-	// Output: [{"version":"1"}]
+	writer.Close()
+
+	// verify versions from the input version with creation times (which depend upon the vault server)
+	versions := []concourse.Version{}
+	if err := json.NewDecoder(reader).Decode(&versions); err != nil {
+		test.Fatalf("check response could not be decoded: %s", err)
+	}
+	if len(versions) == 0 || versions[0].Version != "1" {
+		test.Errorf("expected check versions beginning with version 1, actual: %v", versions)
+	}
+	for _, version := range versions {
+		if _, err := time.Parse(time.RFC3339Nano, version.CreatedTime); err != nil {
+			test.Errorf("version created time is not RFC3339: %s", version.CreatedTime)
+		}
+	}
 }
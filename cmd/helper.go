@@ -1,48 +1,21 @@
 package helper
 
 import (
-	"encoding/json"
-	"log"
-	"os"
-	"strconv"
-
 	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/resource"
 	"github.com/mschuchard/concourse-vault-resource/vault"
 )
 
 // writes inResponse.Metadata marshalled to json to file at /opt/resource/vault.json
+//
+// Deprecated: use resource.SecretsToJSONFile
 func SecretsToJSONFile(filePath string, secretValues concourse.SecretValues) error {
-	// marshal secretValues into json data
-	secretsData, err := json.Marshal(secretValues)
-	if err != nil {
-		log.Print("unable to marshal SecretValues struct to json data")
-		return err
-	}
-	// write secrets to file at /opt/resource/vault.json
-	secretsFile := filePath + "/vault.json"
-	if err = os.WriteFile(secretsFile, secretsData, 0o600); err != nil {
-		log.Printf("error writing secrets to destination file at %s", secretsFile)
-		return err
-	}
-
-	return nil
+	return resource.SecretsToJSONFile(filePath, secretValues)
 }
 
 // converts Vault secret metadata information to Concourse metadata
+//
+// Deprecated: use resource.VaultToConcourseMetadata
 func VaultToConcourseMetadata(prefix string, secretMetadata vault.Metadata) []concourse.MetadataEntry {
-	// return vault metadata lease id, lease duration, and renewable as concourse metadata entries
-	return []concourse.MetadataEntry{
-		{
-			Name:  prefix + "-LeaseID",
-			Value: secretMetadata.LeaseID,
-		},
-		{
-			Name:  prefix + "-LeaseDuration",
-			Value: secretMetadata.LeaseDuration.String(),
-		},
-		{
-			Name:  prefix + "-Renewable",
-			Value: strconv.FormatBool(secretMetadata.Renewable),
-		},
-	}
+	return resource.VaultToConcourseMetadata(prefix, secretMetadata)
 }
@@ -3,15 +3,15 @@ package helper
 import (
 	"os"
 	"slices"
-	"strconv"
 	"testing"
 	"time"
 
 	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/resource"
 	"github.com/mschuchard/concourse-vault-resource/vault"
 )
 
-// minimum coverage testing for helper functions
+// minimum coverage testing for deprecated helper functions
 func TestSecretsToJSONFile(test *testing.T) {
 	secretValues := concourse.SecretValues{"secretValue": {"key": "value"}}
 	if err := SecretsToJSONFile(".", secretValues); err != nil {
@@ -21,33 +21,8 @@ func TestSecretsToJSONFile(test *testing.T) {
 }
 
 func TestVaultToConcourseMetadata(test *testing.T) {
-	duration, _ := time.ParseDuration("65535s")
-	secretMetadata := vault.Metadata{
-		LeaseID:       "abcdefg12345",
-		LeaseDuration: duration,
-		Renewable:     false,
-	}
-	secretPath := "secret-foo/bar"
-
-	concourseMetadata := VaultToConcourseMetadata(secretPath, secretMetadata)
-	expectedConcourseMetadata := []concourse.MetadataEntry{
-		{
-			Name:  secretPath + "-LeaseID",
-			Value: secretMetadata.LeaseID,
-		},
-		{
-			Name:  secretPath + "-LeaseDuration",
-			Value: secretMetadata.LeaseDuration.String(),
-		},
-		{
-			Name:  secretPath + "-Renewable",
-			Value: strconv.FormatBool(secretMetadata.Renewable),
-		},
-	}
-
-	if !slices.Equal(expectedConcourseMetadata, concourseMetadata) {
-		test.Error("vault to concourse metadata conversion returned unexpected value")
-		test.Errorf("expected value: %v", expectedConcourseMetadata)
-		test.Errorf("actual value: %v", concourseMetadata)
+	secretMetadata := vault.Metadata{LeaseID: "abcdefg12345", LeaseDuration: 65535 * time.Second}
+	if concourseMetadata := VaultToConcourseMetadata("secret-foo/bar", secretMetadata); !slices.Equal(concourseMetadata, resource.VaultToConcourseMetadata("secret-foo/bar", secretMetadata)) {
+		test.Errorf("vault to concourse metadata conversion returned unexpected value: %v", concourseMetadata)
 	}
 }
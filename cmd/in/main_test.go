@@ -3,41 +3,32 @@ package main
 import (
 	"os"
 	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
 )
 
-func Example() {
-	// defer stdin close and establish workdir from args[1]
-	defer os.Stdin.Close()
-	os.Args[1] = "/opt/resource"
+func Test(test *testing.T) {
+	// params secrets and source secret with expected vault.json contents
+	for secretKey, secretsContents := range map[string]string{
+		"params": `{"kv-foo/bar":{"PASSWORD":"supersecret"},"secret-bar/baz":{"password":"supersecret"},"secret-foo/bar":{"other_password":"ultrasecret","password":"supersecret"}}`,
+		"source": `{"secret-foo/bar":{"other_password":"ultrasecret","password":"supersecret"}}`,
+	} {
+		// establish workdir from args[1]
+		os.Args[1] = test.TempDir()
 
-	// params secrets and source secret
-	for _, secretKey := range []string{"params", "source"} {
 		// deliver test pipeline file content as stdin to "in" the same as actual pipeline execution
-		os.Stdin, _ = os.OpenFile("fixtures/token_kv_"+secretKey+".json", os.O_RDONLY, 0o644)
+		os.Stdin, _ = util.FixtureFile("fixtures/token_kv_" + secretKey + ".json")
+		defer os.Stdin.Close()
 
-		// invoke main and validate stdout
+		// invoke main
 		main()
-		// Output: {"metadata":[{}],"version":{}}
-	}
-}
-
-func Test(test *testing.T) {
-	// defer stdin close and establish workdir from args[1]
-	defer os.Stdin.Close()
-	os.Args[1] = "/opt/resource"
-
-	// deliver test pipeline file content as stdin to "in" the same as actual pipeline execution
-	os.Stdin, _ = os.OpenFile("fixtures/token_kv_params.json", os.O_RDONLY, 0o644)
-
-	// invoke main and validate stdout
-	main()
 
-	// verify vault.json output
-	secretsContents := `{"kv-foo/bar":{"password":"supersecret"},"secret-bar/baz":{"password":"supersecret"},"secret-foo/bar":{"other_password":"ultrasecret","password":"supersecret"}}`
-	secretsFile, _ := os.ReadFile("/opt/resource/vault.json")
-	if string(secretsFile) != secretsContents {
-		test.Error("vault.json did not contain expected secrets data")
-		test.Errorf("actual file contents: %s", secretsFile)
-		test.Errorf("expected file contents: %s", secretsContents)
+		// verify vault.json output
+		secretsFile, _ := os.ReadFile(os.Args[1] + "/vault.json")
+		if string(secretsFile) != secretsContents {
+			test.Errorf("vault.json did not contain expected secrets data for %s", secretKey)
+			test.Errorf("actual file contents: %s", secretsFile)
+			test.Errorf("expected file contents: %s", secretsContents)
+		}
 	}
 }
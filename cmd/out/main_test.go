@@ -1,16 +1,32 @@
 package main
 
 import (
+	"context"
 	"os"
-	_ "testing"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
 )
 
-func Example() {
+func Test(test *testing.T) {
 	// deliver test pipeline file content as stdin to "out" the same as actual pipeline execution
-	os.Stdin, _ = os.OpenFile("fixtures/token_kv.json", os.O_RDONLY, 0o644)
+	os.Stdin, _ = util.FixtureFile("fixtures/token_kv.json")
 	defer os.Stdin.Close()
 
-	// invoke main and validate stdout
+	// invoke main
 	main()
-	// Output: {"metadata":[{}],"version":{}}
+
+	// verify populated secrets
+	kv2Secret, err := util.VaultClient.KVv2(util.KV2Mount).Get(context.Background(), "thefoo")
+	if err != nil || kv2Secret.Data["newpassword"] != "newsecret" || kv2Secret.Data["newerpassword"] != "newersecret" {
+		test.Errorf("kv2 secret was not populated as expected: %v, error: %v", kv2Secret, err)
+	}
+	kv1Secret, err := util.VaultClient.KVv1(util.KV1Mount).Get(context.Background(), "thebar")
+	if err != nil || kv1Secret.Data["key"] != "value" {
+		test.Errorf("kv1 secret was not populated as expected: %v, error: %v", kv1Secret, err)
+	}
+	copiedSecret, err := util.VaultClient.KVv2(util.KV2Mount).Get(context.Background(), "thecopy")
+	if err != nil || copiedSecret.Data["PASSWORD"] != util.KVValue {
+		test.Errorf("kv1 secret was not copied as expected: %v, error: %v", copiedSecret, err)
+	}
 }
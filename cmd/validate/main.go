@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mschuchard/concourse-vault-resource/logging"
+	"github.com/mschuchard/concourse-vault-resource/pipeline"
+)
+
+// lint the resources of this type in Concourse pipelines before fly set-pipeline
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// validate each pipeline file, and return exit code 0 if valid, 1 if there are findings, or 2 if a pipeline could not be validated
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	resourceTypes := flags.String("type", "", "comma-separated names of the resource types for this resource (default: detected by image repository)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: validate [-type name[,name]] pipeline.yml [pipeline.yml...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	// the findings supersede the log output of the request validation
+	logging.SetOutput(io.Discard)
+
+	var types []string
+	if len(*resourceTypes) > 0 {
+		types = strings.Split(*resourceTypes, ",")
+	}
+
+	exitCode := 0
+	for _, filePath := range flags.Args() {
+		pipelineFile, err := os.Open(filePath)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", filePath, err)
+			exitCode = 2
+			continue
+		}
+		findings, err := pipeline.Validate(pipelineFile, types)
+		pipelineFile.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %s\n", filePath, err)
+			exitCode = 2
+			continue
+		}
+
+		for _, finding := range findings {
+			fmt.Fprintf(stdout, "%s:%s\n", filePath, finding)
+		}
+		if len(findings) > 0 && exitCode == 0 {
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
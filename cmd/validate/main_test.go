@@ -0,0 +1,18 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func Example() {
+	// lint test pipeline file the same as a pre-commit hook
+	exitCode := run([]string{"fixtures/pipeline.yml"}, os.Stdout, os.Stderr)
+	fmt.Println(exitCode)
+	// Output:
+	// fixtures/pipeline.yml:16: resource vault-kv check: at '/source': additional properties 'auth_mout' not allowed
+	// fixtures/pipeline.yml:46: resource vault-kv get: at '/params/secret/paths/0': additional properties 'verison' not allowed
+	// fixtures/pipeline.yml:51: resource vault-kv put: at '/params/secret/patch': got string, want boolean
+	// fixtures/pipeline.yml:59: resource vault-valid put: revoked lease outside of mount
+	// 1
+}
@@ -4,8 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
-	"log"
+	"log/slog"
+	"maps"
 	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/mschuchard/concourse-vault-resource/enum"
 )
@@ -20,25 +24,52 @@ type checkRequest struct {
 type checkResponse []Version
 
 type Source struct {
-	AuthEngine enum.AuthEngine `json:"auth_engine"`
-	Address    string          `json:"address,omitempty"`
-	Insecure   bool            `json:"insecure,omitempty"`
-	AuthMount  string          `json:"auth_mount,omitempty"`
-	VaultRole  string          `json:"vault_role,omitempty"`
-	SecretID   string          `json:"secret_id,omitempty"`
-	Token      string          `json:"token,omitempty"`
-	Secret     SecretSource    `json:"secret"`
+	AuthEngine   enum.AuthEngine `json:"auth_engine"`
+	Address      Addresses       `json:"address,omitempty"`
+	Insecure     bool            `json:"insecure,omitempty"`
+	AuthMount    string          `json:"auth_mount,omitempty"`
+	VaultRole    string          `json:"vault_role,omitempty"`
+	SecretID     string          `json:"secret_id,omitempty"`
+	Token        string          `json:"token,omitempty"`
+	Concurrency  int             `json:"concurrency,omitempty"`
+	MaxRetries   *int            `json:"max_retries,omitempty"`
+	MinRetryWait string          `json:"min_retry_wait,omitempty"`
+	MaxRetryWait string          `json:"max_retry_wait,omitempty"`
+	Timeout      string          `json:"timeout,omitempty"`
+	HMACKey      string          `json:"hmac_key,omitempty"`
+	LogLevel     string          `json:"log_level,omitempty"`
+	LogFormat    string          `json:"log_format,omitempty"`
+	Secret       SecretSource    `json:"secret"`
+	// secrets versioned together as a single composite version
+	Secrets []CompositeSecret `json:"secrets,omitempty"`
 }
 
+// unmarshals from either a single address string or a list of addresses in failover order
+type Addresses []string
+
 type SecretSource struct {
 	Engine  enum.SecretEngine `json:"engine"`
 	Mount   string            `json:"mount"`
 	Path    string            `json:"path"`
 	LeaseId string            `json:"lease_id"`
+	// lease renewal increment duration
+	Increment string `json:"increment"`
+}
+
+// secret of a composite version, or all secrets beneath the path if prefix
+type CompositeSecret struct {
+	Engine enum.SecretEngine `json:"engine"`
+	Mount  string            `json:"mount"`
+	Path   string            `json:"path"`
+	Prefix bool              `json:"prefix,omitempty"`
 }
 
 type Version struct {
 	Version string `json:"version"`
+	// creation time of the version if tracked by the secrets engine
+	CreatedTime string `json:"-"`
+	// per secret breakdown of a composite version with key <mount>-<path>
+	Secrets map[string]string `json:"-"`
 }
 
 // in/get
@@ -51,7 +82,21 @@ type inRequest struct {
 
 type secrets struct {
 	Engine enum.SecretEngine `json:"engine"`
-	Paths  []string          `json:"paths"`
+	Paths  []secretPath      `json:"paths"`
+	// key selection, renaming, and flattening for all paths at the mount
+	Keys    []string          `json:"keys"`
+	Rename  map[string]string `json:"rename"`
+	Flatten bool              `json:"flatten"`
+}
+
+// unmarshals from either a string path or an object with path, version, and key selection, renaming, and flattening
+type secretPath struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	// overrides for the mount key selection, renaming, and flattening
+	Keys    []string          `json:"keys,omitempty"`
+	Rename  map[string]string `json:"rename,omitempty"`
+	Flatten *bool             `json:"flatten,omitempty"`
 }
 
 type response struct {
@@ -73,43 +118,207 @@ type outRequest struct {
 }
 
 type secretsPut struct {
-	Engine enum.SecretEngine `json:"engine"`
-	Patch  bool              `json:"patch"`
+	Engine    enum.SecretEngine `json:"engine"`
+	Patch     bool              `json:"patch"`
+	Namespace string            `json:"namespace"`
+	// compare secrets to current secrets and report the diff without writing or revoking
+	DryRun bool `json:"dry_run"`
 	// key is secret path
 	Secrets SecretValues `json:"secrets"`
+	// key is destination secret path
+	Copy map[string]secretCopy `json:"copy"`
+	// full lease ids and lease id prefixes within the mount to revoke, and metadata file from a get step with lease ids to revoke
+	Revoke         []string `json:"revoke"`
+	RevokePrefix   []string `json:"revoke_prefix"`
+	RevokeFromFile string   `json:"revoke_from_file"`
+}
+
+// source secret for copying to destination secret path
+type secretCopy struct {
+	Engine    enum.SecretEngine `json:"engine"`
+	Mount     string            `json:"mount"`
+	Path      string            `json:"path"`
+	Version   json.Number       `json:"version"` // number or string input
+	Namespace string            `json:"namespace"`
+	Keys      []string          `json:"keys"`   // allow-list of keys to copy
+	Rename    map[string]string `json:"rename"` // key is source key, and value is destination key
 }
 
 type SecretValues map[string]secretValue // key is secret "<mount>-<path>", and value is secret keys and values
 
 type secretValue map[string]any // key-value pairs would be arbitrary for kv1 and kv2, but are standardized schema for credential generators
 
-// lease id validation regex for below constructor
-var leaseIDRegex = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+// secretPath custom unmarshal for backwards compatibility with string paths
+func (secretPath *secretPath) UnmarshalJSON(data []byte) error {
+	// path specified as string with implicit latest version
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		secretPath.Path = path
+		return nil
+	}
+
+	// path specified as object with optional version as either number or string
+	var pathVersion struct {
+		Path    string            `json:"path"`
+		Version json.Number       `json:"version"`
+		Keys    []string          `json:"keys"`
+		Rename  map[string]string `json:"rename"`
+		Flatten *bool             `json:"flatten"`
+	}
+	if err := json.Unmarshal(data, &pathVersion); err != nil {
+		slog.Error("the params path entry is neither a string nor an object with path and version", "entry", string(data), "error", err)
+		return err
+	}
+	secretPath.Path = pathVersion.Path
+	secretPath.Version = pathVersion.Version.String()
+	secretPath.Keys = pathVersion.Keys
+	secretPath.Rename = pathVersion.Rename
+	secretPath.Flatten = pathVersion.Flatten
+
+	return nil
+}
+
+// Addresses custom unmarshal for a single address string or a list of addresses
+func (addresses *Addresses) UnmarshalJSON(data []byte) error {
+	// single address specified as string
+	var address string
+	if err := json.Unmarshal(data, &address); err == nil {
+		*addresses = Addresses{address}
+		return nil
+	}
+
+	// list of addresses in failover order
+	var addressList []string
+	if err := json.Unmarshal(data, &addressList); err != nil {
+		slog.Error("the address is neither a string nor a list of strings", "address", string(data), "error", err)
+		return err
+	}
+	*addresses = addressList
+
+	return nil
+}
+
+// Version custom marshal flattens the creation time and the per secret breakdown of a composite version into the version map
+func (version Version) MarshalJSON() ([]byte, error) {
+	versionMap := map[string]string{}
+	maps.Copy(versionMap, version.Secrets)
+	versionMap["version"] = version.Version
+	if len(version.CreatedTime) > 0 {
+		versionMap["created_time"] = version.CreatedTime
+	}
+
+	return json.Marshal(versionMap)
+}
+
+// Version custom unmarshal for the version map with the per secret breakdown of a composite version
+func (version *Version) UnmarshalJSON(data []byte) error {
+	var versionMap map[string]string
+	if err := json.Unmarshal(data, &versionMap); err != nil {
+		slog.Error("the version is not a map of strings", "version", string(data), "error", err)
+		return err
+	}
+
+	version.Version = versionMap["version"]
+	version.CreatedTime = versionMap["created_time"]
+	delete(versionMap, "version")
+	delete(versionMap, "created_time")
+	if len(versionMap) > 0 {
+		version.Secrets = versionMap
+	}
+
+	return nil
+}
+
+// version is unspecified
+func (version Version) IsZero() bool {
+	return len(version.Version) == 0 && len(version.CreatedTime) == 0 && len(version.Secrets) == 0
+}
+
+// the secrets and copies are compared to the current secrets without writing (revocations alone may be dry run for any mount)
+func (secretsPut secretsPut) DryRunSecrets() bool {
+	return secretsPut.DryRun && len(secretsPut.Secrets)+len(secretsPut.Copy) > 0
+}
+
+// EngineValidator validates that the secret engines support the operations of a request (registered by the package implementing the secret engines to avoid an import cycle)
+type EngineValidator interface {
+	// validate the secret engines of the composite secrets in source support checks and prefixes
+	ValidateCompositeSecrets(source Source) error
+	// validate the secret engine supports comparison of secret values for dry runs
+	ValidateDiff(engine enum.SecretEngine) error
+}
+
+// secret engine validator for request constructors (nil signifies the secret engines are not validated)
+var engineValidator EngineValidator
+
+// register the secret engine validator for request constructors (typically invoked during package initialization)
+func RegisterEngineValidator(validator EngineValidator) {
+	engineValidator = validator
+}
+
+// validate composite secrets in source
+func (source Source) validateSecrets() error {
+	if len(source.Secrets) == 0 {
+		return nil
+	}
+	if source.Secret != (SecretSource{}) {
+		slog.Error("a secret and composite secrets cannot be simultaneously specified in source")
+		return errors.New("dual source secrets specified")
+	}
+
+	for _, compositeSecret := range source.Secrets {
+		// mount is required as the secret identifier in the version breakdown, and path is optional only for prefixes at the mount root
+		if len(compositeSecret.Mount) == 0 || (len(compositeSecret.Path) == 0 && !compositeSecret.Prefix) {
+			slog.Error("the composite secret must specify a mount and a path", "engine", compositeSecret.Engine, "mount", compositeSecret.Mount, "path", compositeSecret.Path)
+			return errors.New("required param(s) missing")
+		}
+	}
+
+	// validate the secret engines of the composite secrets
+	if engineValidator != nil {
+		return engineValidator.ValidateCompositeSecrets(source)
+	}
+
+	return nil
+}
+
+// lease id validation regex for below constructor with optional lease path prefix, and then either legacy uuid or current random id with optional namespace id
+var leaseIDRegex = regexp.MustCompile(`^([\w.\-]+/)*([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[a-zA-Z0-9]{24})(\.[a-zA-Z0-9]+)?$`)
 
 // checkRequest constructor with pipeline param as io.Reader but typically os.Stdin *os.File input because concourse
 func NewCheckRequest(pipelineJSON io.Reader) (*checkRequest, error) {
-	// read, decode, and unmarshal the pipeline json io.Reader, and assign to the inRequest pointer
+	// read, validate against the schema, decode, and unmarshal the pipeline json io.Reader, and assign to the checkRequest pointer
 	var checkRequest checkRequest
-	if err := json.NewDecoder(pipelineJSON).Decode(&checkRequest); err != nil {
-		log.Print("error decoding pipeline input from JSON")
+	if err := decodeRequest(pipelineJSON, "checkRequest", &checkRequest); err != nil {
+		return nil, err
+	}
+
+	// validate composite secrets
+	if err := checkRequest.Source.validateSecrets(); err != nil {
 		return nil, err
 	}
 
-	// validate version not specified for kv1
+	// info message for version specified with kv1 since the input version is the previous digest version and is ignored
 	secretSource := checkRequest.Source.Secret
-	if secretSource.Engine == enum.KeyValue1 && checkRequest.Version != (Version{}) {
-		log.Print("version cannot be specified in conjunction with a kv version 1 engine secret")
-		return nil, errors.New("secret version specified with kv1")
+	if secretSource.Engine == enum.KeyValue1 && !checkRequest.Version.IsZero() {
+		slog.Info("the input version is ignored for a kv version 1 engine secret, and the digest of the current secret value is returned", "version", checkRequest.Version.Version)
 	}
 
 	// validate lease id if specified
 	if len(secretSource.LeaseId) > 0 {
 		if !leaseIDRegex.MatchString(secretSource.LeaseId) {
-			log.Printf("the specified lease id %s is invalid", secretSource.LeaseId)
+			slog.Error("the specified lease id is invalid", "lease_id", secretSource.LeaseId)
 			return nil, errors.New("invalid lease id parameter")
 		}
 	}
 
+	// validate lease increment if specified
+	if len(secretSource.Increment) > 0 {
+		if increment, err := time.ParseDuration(secretSource.Increment); err != nil || increment <= 0 {
+			slog.Error("the specified lease increment is not a valid positive duration (e.g. 1h)", "increment", secretSource.Increment)
+			return nil, errors.New("invalid lease increment parameter")
+		}
+	}
+
 	return &checkRequest, nil
 }
 
@@ -121,35 +330,77 @@ func NewCheckResponse(versions []Version) checkResponse {
 
 // inRequest constructor with pipeline param as io.Reader but typically os.Stdin *os.File input because concourse
 func NewInRequest(pipelineJSON io.Reader) (*inRequest, error) {
-	// read, decode, and unmarshal the pipeline json io.Reader, and assign to the inRequest pointer
+	// read, validate against the schema, decode, and unmarshal the pipeline json io.Reader, and assign to the inRequest pointer
 	var inRequest inRequest
-	if err := json.NewDecoder(pipelineJSON).Decode(&inRequest); err != nil {
-		log.Print("error decoding pipeline input from JSON")
+	if err := decodeRequest(pipelineJSON, "inRequest", &inRequest); err != nil {
+		return nil, err
+	}
+
+	// validate composite secrets
+	if err := inRequest.Source.validateSecrets(); err != nil {
 		return nil, err
 	}
 
 	// these conditionals are evaluated multiple times so assign here
-	noSourceSecret := inRequest.Source.Secret == (SecretSource{})
+	noSourceSecret := inRequest.Source.Secret == (SecretSource{}) && len(inRequest.Source.Secrets) == 0
 	noParamsSecret := inRequest.Params == nil
 
 	// info message for request version specified and params usage
-	if inRequest.Version != (Version{}) && !noParamsSecret {
-		log.Print("version is ignored in the get step with params as it must be tied to a specific secret path")
+	if !inRequest.Version.IsZero() && !noParamsSecret {
+		slog.Warn("version is ignored in the get step with params as it must be tied to a specific secret path, and a version may instead be specified for each path in params", "version", inRequest.Version.Version)
 	}
 
-	// validate params versus source.secret
-	if !noSourceSecret && !noParamsSecret {
-		log.Print("secrets cannot be simultaneously specified in both source and params")
+	// validate params versus source.secret, but composite secrets in source may only trigger the params secrets retrieval
+	if len(inRequest.Source.Secrets) > 0 && !noParamsSecret {
+		slog.Info("the params secrets will be retrieved instead of the composite secrets in source")
+	} else if !noSourceSecret && !noParamsSecret {
+		slog.Error("secrets cannot be simultaneously specified in both source and params")
 		return nil, errors.New("dual secrets specified")
 	} else if noSourceSecret && noParamsSecret {
-		log.Print("one secret must be specified in source, or one or more secrets in params, and neither was specified")
+		slog.Error("one secret must be specified in source, or one or more secrets in params, and neither was specified")
 		return nil, errors.New("no secrets specified")
 	}
 
+	// validate concurrency for params secrets retrieval
+	if inRequest.Source.Concurrency < 0 {
+		slog.Error("the specified concurrency must not be negative", "concurrency", inRequest.Source.Concurrency)
+		return nil, errors.New("invalid concurrency")
+	}
+
+	// validate params paths and versions
+	for mount, secretParams := range inRequest.Params {
+		for _, secretPath := range secretParams.Paths {
+			if len(secretPath.Path) == 0 {
+				slog.Error("an empty path was specified for the secrets at mount", "mount", mount)
+				return nil, errors.New("empty secret path")
+			}
+			if len(secretPath.Version) > 0 && secretParams.Engine != enum.KeyValue2 {
+				slog.Error("versions are only supported with the kv2 engine", "engine", secretParams.Engine, "mount", mount, "path", secretPath.Path, "version", secretPath.Version)
+				return nil, errors.New("secret version specified with non-kv2 engine")
+			}
+		}
+	}
+
 	// return reference
 	return &inRequest, nil
 }
 
+// return key selection, renaming, and flattening for the path with path overrides of mount values
+func (secrets secrets) Shape(secretPath secretPath) ([]string, map[string]string, bool) {
+	keys, rename, flatten := secrets.Keys, secrets.Rename, secrets.Flatten
+	if len(secretPath.Keys) > 0 {
+		keys = secretPath.Keys
+	}
+	if len(secretPath.Rename) > 0 {
+		rename = secretPath.Rename
+	}
+	if secretPath.Flatten != nil {
+		flatten = *secretPath.Flatten
+	}
+
+	return keys, rename, flatten
+}
+
 // in/out response constructor
 func NewResponse() *response {
 	// return initialized reference
@@ -161,21 +412,54 @@ func NewResponse() *response {
 
 // outRequest constructor with pipeline param as io.Reader but typically os.Stdin *os.File input because concourse
 func NewOutRequest(pipelineJSON io.Reader) (*outRequest, error) {
-	// read, decode, and unmarshal the pipeline json io.Reader, and assign to the outRequest pointer
+	// read, validate against the schema, decode, and unmarshal the pipeline json io.Reader, and assign to the outRequest pointer
 	var outRequest outRequest
-	if err := json.NewDecoder(pipelineJSON).Decode(&outRequest); err != nil {
-		log.Print("error decoding pipeline input from JSON")
+	if err := decodeRequest(pipelineJSON, "outRequest", &outRequest); err != nil {
 		return nil, err
 	}
 	// validate
 	if outRequest.Source.Secret != (SecretSource{}) {
-		log.Print("specifying a secret in source for a put step has no effect, and that value will be ignored during this step execution")
+		slog.Warn("specifying a secret in source for a put step has no effect, and that value will be ignored during this step execution")
 	}
 	if outRequest.Params == nil {
-		log.Print("no secret parameters were specified for this put step")
+		slog.Warn("no secret parameters were specified for this put step")
 		return nil, errors.New("empty params")
 	}
 
+	// validate dry runs, secret copies, and revocations
+	for mount, secretParams := range outRequest.Params {
+		if secretParams.DryRunSecrets() && engineValidator != nil {
+			if err := engineValidator.ValidateDiff(secretParams.Engine); err != nil {
+				return nil, err
+			}
+		}
+		for _, leaseId := range slices.Concat(secretParams.Revoke, secretParams.RevokePrefix) {
+			if !strings.HasPrefix(leaseId+"/", mount+"/") {
+				slog.Error("the lease ID or prefix to revoke is not within the mount", "lease_id", leaseId, "mount", mount)
+				return nil, errors.New("revoked lease outside of mount")
+			}
+		}
+
+		for secretPath, secretCopy := range secretParams.Copy {
+			if _, ok := secretParams.Secrets[secretPath]; ok {
+				slog.Error("the secret was specified in both secrets and copy", "mount", mount, "path", secretPath)
+				return nil, errors.New("secret path specified in both secrets and copy")
+			}
+			if secretCopy.Engine != enum.KeyValue1 && secretCopy.Engine != enum.KeyValue2 {
+				slog.Error("the copy source engine must be kv1 or kv2", "engine", secretCopy.Engine, "mount", mount, "path", secretPath)
+				return nil, errors.New("invalid copy source engine")
+			}
+			if len(secretCopy.Path) == 0 {
+				slog.Error("the copy source path is mandatory", "mount", mount, "path", secretPath)
+				return nil, errors.New("empty copy source path")
+			}
+			if len(secretCopy.Version) > 0 && secretCopy.Engine != enum.KeyValue2 {
+				slog.Error("versions are only supported with the kv2 engine for the copy source", "engine", secretCopy.Engine, "mount", mount, "path", secretPath, "version", secretCopy.Version)
+				return nil, errors.New("secret version specified with non-kv2 engine")
+			}
+		}
+	}
+
 	// return reference
 	return &outRequest, nil
 }
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"maps"
 	"os"
+	"reflect"
 	"slices"
+	"strings"
 	"testing"
 )
 
@@ -28,11 +30,11 @@ func TestCheckRequest(test *testing.T) {
 	source := checkRequest.Source
 	expectedSecretSource := SecretSource{Engine: "kv2", Mount: "secret", Path: "foo/bar"}
 
-	if checkRequest.Version != version || source.AuthEngine != "token" || source.Address != "http://localhost:8200" || !source.Insecure || source.Token != "abcdefghijklmnopqrstuvwxyz09" || source.VaultRole != "myrole" || source.AuthMount != "placeholder" || source.SecretID != "abcd-1234-5678-efgh-ijklmnop" || source.Secret != expectedSecretSource {
+	if !reflect.DeepEqual(checkRequest.Version, version) || source.AuthEngine != "token" || !slices.Equal(source.Address, Addresses{"http://localhost:8200"}) || !source.Insecure || source.Token != "abcdefghijklmnopqrstuvwxyz09" || source.VaultRole != "myrole" || source.AuthMount != "placeholder" || source.SecretID != "abcd-1234-5678-efgh-ijklmnop" || source.Secret != expectedSecretSource {
 		test.Error("check request constructor returned unexpected values")
 		test.Errorf("expected Version field to be %v, actual: %v", version, checkRequest.Version)
 		test.Errorf("expected Source Auth Engine field to be: token, actual: %s", source.AuthEngine)
-		test.Errorf("expected Source Address field to be: http://localhost:8200, actual: %s", source.Address)
+		test.Errorf("expected Source Address field to be: [http://localhost:8200], actual: %v", source.Address)
 		test.Errorf("expected Source Insecure field to be: true, actual: %t", source.Insecure)
 		test.Errorf("expected Source Token field to be: abcdefghijklmnopqrstuvwxyz09, actual: %s", source.Token)
 		test.Errorf("expected Source Vault Role field to be: myrole, actual: %s", source.VaultRole)
@@ -49,13 +51,93 @@ func TestCheckRequest(test *testing.T) {
 	if _, err = NewCheckRequest(pipelineJSON); err == nil || err.Error() != "invalid lease id parameter" {
 		test.Error("invalid lease id parameter value did not fail validation")
 	}
+
+	// lease id formats
+	for leaseId, valid := range map[string]bool{
+		"27e1b9a1-27b8-83d9-9fe0-d99d786bdc83":                         true,
+		"database/creds/readonly/27e1b9a1-27b8-83d9-9fe0-d99d786bdc83": true,
+		"aws/sts/deploy/Yh8Xb2cABx8WQwYoVm1kC3Hq":                      true,
+		"ssh/creds/otp/Yh8Xb2cABx8WQwYoVm1kC3Hq.nsid1":                 true,
+		"abcdefg-1234":                           false,
+		"database/creds/readonly/":               false,
+		"database/../Yh8Xb2cABx8WQwYoVm1kC3Hq x": false,
+	} {
+		_, err = NewCheckRequest(strings.NewReader(`{"source": {"secret": {"engine": "database", "path": "readonly", "lease_id": "` + leaseId + `"}}}`))
+		if valid && err != nil {
+			test.Errorf("valid lease id %s failed validation: %s", leaseId, err)
+		} else if !valid && (err == nil || err.Error() != "invalid lease id parameter") {
+			test.Errorf("invalid lease id %s did not fail validation: %v", leaseId, err)
+		}
+	}
+
+	// lease increment
+	if _, err = NewCheckRequest(strings.NewReader(`{"source": {"secret": {"engine": "database", "path": "readonly", "increment": "1h"}}}`)); err != nil {
+		test.Errorf("valid lease increment failed validation: %s", err)
+	}
+	if _, err = NewCheckRequest(strings.NewReader(`{"source": {"secret": {"engine": "database", "path": "readonly", "increment": "3600"}}}`)); err == nil || err.Error() != "invalid lease increment parameter" {
+		test.Errorf("expected error: invalid lease increment parameter, actual: %v", err)
+	}
+
+	// composite secrets
+	compositeRequest, err := NewCheckRequest(strings.NewReader(`{"source": {"hmac_key": "key", "secrets": [{"engine": "kv2", "mount": "secret", "path": "app", "prefix": true}, {"engine": "kv1", "mount": "kv", "path": "foo/bar"}]}, "version": {"version": "abc", "secret-app/db": "2"}}`))
+	expectedSecrets := []CompositeSecret{{Engine: "kv2", Mount: "secret", Path: "app", Prefix: true}, {Engine: "kv1", Mount: "kv", Path: "foo/bar"}}
+	if err != nil || !reflect.DeepEqual(compositeRequest.Source.Secrets, expectedSecrets) || !reflect.DeepEqual(compositeRequest.Version, Version{Version: "abc", Secrets: map[string]string{"secret-app/db": "2"}}) {
+		test.Errorf("composite secrets check request was unexpected: %v, error: %v", compositeRequest, err)
+	}
+	for compositeJSON, expectedErr := range map[string]string{
+		`{"secret": {"engine": "kv2", "path": "foo"}, "secrets": [{"engine": "kv2", "mount": "secret", "path": "bar"}]}`: "dual source secrets specified",
+		`{"secrets": [{"engine": "kv2", "path": "bar"}]}`:                                                                "required param(s) missing",
+	} {
+		if _, err = NewCheckRequest(strings.NewReader(`{"source": ` + compositeJSON + `}`)); err == nil || err.Error() != expectedErr {
+			test.Errorf("expected error: %s, actual: %v", expectedErr, err)
+		}
+	}
+
+	// kv1 digest version from previous check
+	if _, err = NewCheckRequest(strings.NewReader(`{"source": {"hmac_key": "key", "secret": {"engine": "kv1", "path": "foo/bar"}}, "version": {"version": "abcdef0123456789"}}`)); err != nil {
+		test.Errorf("kv1 digest version failed validation: %s", err)
+	}
+}
+
+// test version custom marshal and unmarshal
+func TestVersionJSON(test *testing.T) {
+	compositeVersion := Version{Version: "abc", Secrets: map[string]string{"secret-app/db": "2", "kv-foo/bar": "def"}}
+	versionJSON, err := json.Marshal(compositeVersion)
+	if err != nil || string(versionJSON) != `{"kv-foo/bar":"def","secret-app/db":"2","version":"abc"}` {
+		test.Errorf("unexpected composite version json: %s, error: %v", versionJSON, err)
+	}
+	var unmarshalledVersion Version
+	if err = json.Unmarshal(versionJSON, &unmarshalledVersion); err != nil || !reflect.DeepEqual(unmarshalledVersion, compositeVersion) {
+		test.Errorf("expected composite version: %v, actual: %v, error: %v", compositeVersion, unmarshalledVersion, err)
+	}
+
+	// single secret version
+	if versionJSON, _ = json.Marshal(version); string(versionJSON) != `{"version":"1"}` {
+		test.Errorf("unexpected version json: %s", versionJSON)
+	}
+	unmarshalledVersion = Version{}
+	if err = json.Unmarshal([]byte(`{"version":"1"}`), &unmarshalledVersion); err != nil || !reflect.DeepEqual(unmarshalledVersion, version) || unmarshalledVersion.IsZero() {
+		test.Errorf("expected version: %v, actual: %v, error: %v", version, unmarshalledVersion, err)
+	}
+	// version with creation time
+	createdVersion := Version{Version: "3", CreatedTime: "2024-01-01T00:00:00Z"}
+	if versionJSON, _ = json.Marshal(createdVersion); string(versionJSON) != `{"created_time":"2024-01-01T00:00:00Z","version":"3"}` {
+		test.Errorf("unexpected version json with creation time: %s", versionJSON)
+	}
+	unmarshalledVersion = Version{}
+	if err = json.Unmarshal(versionJSON, &unmarshalledVersion); err != nil || !reflect.DeepEqual(unmarshalledVersion, createdVersion) {
+		test.Errorf("expected version: %v, actual: %v, error: %v", createdVersion, unmarshalledVersion, err)
+	}
+	if !(Version{}).IsZero() {
+		test.Error("empty version was not zero")
+	}
 }
 
 // test checkresponse constructor
 func TestCheckResponse(test *testing.T) {
 	checkResponse := NewCheckResponse([]Version{version})
 
-	if len(checkResponse) != 1 || checkResponse[0] != version {
+	if len(checkResponse) != 1 || !reflect.DeepEqual(checkResponse[0], version) {
 		test.Error("the check response constructor returned an unexpected value")
 		test.Errorf("expected value: &[], actual: %v", checkResponse)
 	}
@@ -87,11 +169,95 @@ func TestNewInRequest(test *testing.T) {
 	params := newInRequest.Params
 	expectedParams := expectedIn.Params
 
-	if source != expectedSource || params["secret"].Engine != expectedParams["secret"].Engine || !slices.Equal(params["secret"].Paths, expectedParams["secret"].Paths) || params["kv"].Engine != expectedParams["kv"].Engine || !slices.Equal(params["kv"].Paths, expectedParams["kv"].Paths) {
+	if !reflect.DeepEqual(source, expectedSource) || params["secret"].Engine != expectedParams["secret"].Engine || !reflect.DeepEqual(params["secret"].Paths, expectedParams["secret"].Paths) || params["kv"].Engine != expectedParams["kv"].Engine || !reflect.DeepEqual(params["kv"].Paths, expectedParams["kv"].Paths) {
 		test.Error("in request constructor returned unexpected values")
 		test.Errorf("expected Source field to be %v, actual: %v", expectedSource, source)
 		test.Errorf("expected Params field to be %v, actual: %v", expectedParams, params)
 	}
+
+	expectedPaths := []secretPath{{Path: "foo/bar", Version: "1"}, {Path: "bar/baz"}}
+	if !reflect.DeepEqual(params["secret"].Paths, expectedPaths) {
+		test.Error("in request constructor returned unexpected params paths")
+		test.Errorf("expected paths: %v, actual: %v", expectedPaths, params["secret"].Paths)
+	}
+
+	// test errors
+	kv1Version := strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"kv": {"engine": "kv1", "paths": [{"path": "foo/bar", "version": "1"}]}}}`)
+	if _, err = NewInRequest(kv1Version); err == nil || err.Error() != "secret version specified with non-kv2 engine" {
+		test.Errorf("expected error: secret version specified with non-kv2 engine, actual: %v", err)
+	}
+
+	emptyPath := strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"secret": {"engine": "kv2", "paths": [{"version": 2}]}}}`)
+	if _, err = NewInRequest(emptyPath); err == nil || err.Error() != "empty secret path" {
+		test.Errorf("expected error: empty secret path, actual: %v", err)
+	}
+
+	negativeConcurrency := strings.NewReader(`{"source": {"auth_engine": "token", "concurrency": -1}, "params": {"secret": {"engine": "kv2", "paths": ["foo/bar"]}}}`)
+	if _, err = NewInRequest(negativeConcurrency); err == nil || err.Error() != "invalid concurrency" {
+		test.Errorf("expected error: invalid concurrency, actual: %v", err)
+	}
+
+	// composite secrets in source with or without params
+	for _, compositeJSON := range []string{
+		`{"source": {"auth_engine": "token", "secrets": [{"engine": "kv2", "mount": "secret", "path": "app", "prefix": true}]}}`,
+		`{"source": {"auth_engine": "token", "secrets": [{"engine": "kv2", "mount": "secret", "path": "app", "prefix": true}]}, "params": {"secret": {"engine": "kv2", "paths": [{"path": "app/db"}]}}}`,
+	} {
+		if _, err = NewInRequest(strings.NewReader(compositeJSON)); err != nil {
+			test.Errorf("in request with composite secrets failed to construct: %s", err)
+		}
+	}
+}
+
+// test addresses unmarshal from string or list
+func TestAddressesUnmarshalJSON(test *testing.T) {
+	var source Source
+	if err := json.Unmarshal([]byte(`{"address": "https://vault.example.com:8200"}`), &source); err != nil || !slices.Equal(source.Address, Addresses{"https://vault.example.com:8200"}) {
+		test.Errorf("single address unmarshalled to unexpected value: %v, error: %v", source.Address, err)
+	}
+	if err := json.Unmarshal([]byte(`{"address": ["https://primary.example.com:8200", "https://dr.example.com:8200"]}`), &source); err != nil || !slices.Equal(source.Address, Addresses{"https://primary.example.com:8200", "https://dr.example.com:8200"}) {
+		test.Errorf("address list unmarshalled to unexpected value: %v, error: %v", source.Address, err)
+	}
+	if err := json.Unmarshal([]byte(`{"address": 8200}`), &source); err == nil {
+		test.Error("invalid address did not error")
+	}
+}
+
+// test key selection, renaming, and flattening resolution for path
+func TestSecretsShape(test *testing.T) {
+	mountSecrets := secrets{Keys: []string{"username", "password"}, Rename: map[string]string{"password": "PASSWORD"}, Flatten: true}
+
+	// mount values
+	if keys, rename, flatten := mountSecrets.Shape(secretPath{Path: "foo/bar"}); !slices.Equal(keys, mountSecrets.Keys) || !maps.Equal(rename, mountSecrets.Rename) || !flatten {
+		test.Errorf("unexpected mount shape: %v, %v, %t", keys, rename, flatten)
+	}
+
+	// path overrides
+	noFlatten := false
+	pathOverride := secretPath{Path: "foo/bar", Keys: []string{"password"}, Rename: map[string]string{"password": "DB_PASSWORD"}, Flatten: &noFlatten}
+	if keys, rename, flatten := mountSecrets.Shape(pathOverride); !slices.Equal(keys, pathOverride.Keys) || !maps.Equal(rename, pathOverride.Rename) || flatten {
+		test.Errorf("unexpected path override shape: %v, %v, %t", keys, rename, flatten)
+	}
+}
+
+// test secret path unmarshal from string or object
+func TestSecretPathUnmarshalJSON(test *testing.T) {
+	var paths []secretPath
+	if err := json.Unmarshal([]byte(`["foo/bar", {"path": "bar/baz", "version": 3}, {"path": "baz/bat", "version": "4", "keys": ["password"], "rename": {"password": "DB_PASSWORD"}, "flatten": false}]`), &paths); err != nil {
+		test.Error("secret paths failed to unmarshal")
+		test.Error(err)
+	}
+
+	flatten := false
+	expectedPaths := []secretPath{{Path: "foo/bar"}, {Path: "bar/baz", Version: "3"}, {Path: "baz/bat", Version: "4", Keys: []string{"password"}, Rename: map[string]string{"password": "DB_PASSWORD"}, Flatten: &flatten}}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		test.Error("secret paths unmarshalled to unexpected values")
+		test.Errorf("expected values: %v", expectedPaths)
+		test.Errorf("actual values: %v", paths)
+	}
+
+	if err := json.Unmarshal([]byte(`[1]`), &paths); err == nil {
+		test.Error("invalid secret path did not fail to unmarshal")
+	}
 }
 
 // test inResponse constructor
@@ -132,11 +298,57 @@ func TestNewOutRequest(test *testing.T) {
 	params := newOutRequest.Params
 	expectedParams := expectedOut.Params
 
-	if source != expectedSource || params["secret"].Engine != expectedParams["secret"].Engine || !maps.Equal(params["secret"].Secrets["thefoo"], expectedParams["secret"].Secrets["thefoo"]) || params["kv"].Engine != expectedParams["kv"].Engine || !maps.Equal(params["kv"].Secrets["thebar"], expectedParams["kv"].Secrets["thebar"]) || !maps.Equal(params["kv"].Secrets["thebaz"], expectedParams["kv"].Secrets["thebaz"]) {
+	if !reflect.DeepEqual(source, expectedSource) || params["secret"].Engine != expectedParams["secret"].Engine || !maps.Equal(params["secret"].Secrets["thefoo"], expectedParams["secret"].Secrets["thefoo"]) || params["kv"].Engine != expectedParams["kv"].Engine || !maps.Equal(params["kv"].Secrets["thebar"], expectedParams["kv"].Secrets["thebar"]) || !maps.Equal(params["kv"].Secrets["thebaz"], expectedParams["kv"].Secrets["thebaz"]) {
 		test.Error("out request constructor returned unexpected values")
 		test.Errorf("expected Source field to be %v, actual: %v", expectedSource, source)
 		test.Errorf("expected Params field to be %v, actual: %v", expectedParams, params)
 	}
+
+	secretCopy := params["secret"].Copy["thecopy"]
+	if secretCopy.Engine != "kv1" || secretCopy.Mount != "kv" || secretCopy.Path != "foo/bar" || secretCopy.Rename["password"] != "PASSWORD" {
+		test.Error("out request constructor returned unexpected copy params")
+		test.Errorf("actual copy params: %v", secretCopy)
+	}
+
+	// test errors
+	dualPath := strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"secret": {"engine": "kv2", "secrets": {"foo": {"key": "value"}}, "copy": {"foo": {"engine": "kv2", "path": "bar"}}}}}`)
+	if _, err = NewOutRequest(dualPath); err == nil || err.Error() != "secret path specified in both secrets and copy" {
+		test.Errorf("expected error: secret path specified in both secrets and copy, actual: %v", err)
+	}
+
+	dynamicCopy := strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"secret": {"engine": "kv2", "copy": {"foo": {"engine": "database", "path": "bar"}}}}}`)
+	if _, err = NewOutRequest(dynamicCopy); err == nil || err.Error() != "invalid copy source engine" {
+		test.Errorf("expected error: invalid copy source engine, actual: %v", err)
+	}
+
+	kv1Version := strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"secret": {"engine": "kv2", "copy": {"foo": {"engine": "kv1", "path": "bar", "version": 2}}}}}`)
+	if _, err = NewOutRequest(kv1Version); err == nil || err.Error() != "secret version specified with non-kv2 engine" {
+		test.Errorf("expected error: secret version specified with non-kv2 engine, actual: %v", err)
+	}
+
+	revokeOutsideMount := strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"database": {"revoke": ["aws/creds/readonly/abcd"]}}}`)
+	if _, err = NewOutRequest(revokeOutsideMount); err == nil || err.Error() != "revoked lease outside of mount" {
+		test.Errorf("expected error: revoked lease outside of mount, actual: %v", err)
+	}
+	// revocations alone may be dry run for any mount
+	for paramsJSON, expected := range map[string]bool{
+		`{"secret": {"engine": "kv2", "dry_run": true, "secrets": {"foo": {"bar": "baz"}}}}`: true,
+		`{"database": {"dry_run": true, "revoke": ["database/creds/readonly/abcd"]}}`:        false,
+	} {
+		if outRequest, err := NewOutRequest(strings.NewReader(`{"source": {"auth_engine": "token"}, "params": ` + paramsJSON + `}`)); err != nil {
+			test.Errorf("dry run out request failed to construct: %s", err)
+		} else {
+			for _, secretParams := range outRequest.Params {
+				if secretParams.DryRunSecrets() != expected {
+					test.Errorf("expected dry run of secrets for %s: %t", paramsJSON, expected)
+				}
+			}
+		}
+	}
+	revokePrefixOutsideMount := strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"database": {"revoke_prefix": ["databases"]}}}`)
+	if _, err = NewOutRequest(revokePrefixOutsideMount); err == nil || err.Error() != "revoked lease outside of mount" {
+		test.Errorf("expected error: revoked lease outside of mount, actual: %v", err)
+	}
 }
 
 // test outResponse constructor
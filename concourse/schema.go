@@ -0,0 +1,75 @@
+package concourse
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// JSON Schema of the check, in, and out requests with definitions for source, in params, and out params
+//
+//go:embed schema.json
+var Schema []byte
+
+// location of the schema resource for compilation matching its $id
+const schemaURL = "https://github.com/mschuchard/concourse-vault-resource/concourse/schema.json"
+
+// compiler with the schema resource added once for all requests
+var schemaCompiler = sync.OnceValues(func() (*jsonschema.Compiler, error) {
+	schemaDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(Schema))
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err = compiler.AddResource(schemaURL, schemaDoc); err != nil {
+		return nil, err
+	}
+
+	return compiler, nil
+})
+
+// validate pipeline json against the schema definition, and then decode it into the request while rejecting unknown fields
+func decodeRequest(pipelineJSON io.Reader, definition string, request any) error {
+	pipelineData, err := io.ReadAll(pipelineJSON)
+	if err != nil {
+		slog.Error("error reading pipeline input", "error", err)
+		return err
+	}
+
+	// validate against the schema definition for the request
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(pipelineData))
+	if err != nil {
+		slog.Error("error decoding pipeline input from JSON", "error", err)
+		return err
+	}
+	compiler, err := schemaCompiler()
+	if err != nil {
+		slog.Error("the request schema could not be loaded", "error", err)
+		return err
+	}
+	schema, err := compiler.Compile(schemaURL + "#/$defs/" + definition)
+	if err != nil {
+		slog.Error("the request schema could not be compiled", "definition", definition, "error", err)
+		return err
+	}
+	if err = schema.Validate(instance); err != nil {
+		slog.Error("the pipeline input does not conform to the request schema", "definition", definition, "error", err)
+		return err
+	}
+
+	// decode, and unmarshal the pipeline json, and assign to the request pointer
+	decoder := json.NewDecoder(bytes.NewReader(pipelineData))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(request); err != nil {
+		slog.Error("error decoding pipeline input from JSON", "error", err)
+		return err
+	}
+
+	return nil
+}
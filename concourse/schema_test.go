@@ -0,0 +1,91 @@
+package concourse
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// test schema validation and unknown field rejection for each request
+func TestDecodeRequest(test *testing.T) {
+	for definition, pipelineJSON := range map[string]string{
+		"checkRequest": `{"source": {"auth_engine": "token", "auth_mout": "token"}, "version": null}`,
+		"inRequest":    `{"source": {"auth_engine": "token"}, "params": {"secret": {"engine": "kv2", "paths": [{"path": "foo", "verison": 1}]}}}`,
+		"outRequest":   `{"source": {"auth_engine": "token"}, "params": {"secret": {"engine": "kv2", "pach": true}}}`,
+	} {
+		var request map[string]any
+		err := decodeRequest(strings.NewReader(pipelineJSON), definition, &request)
+		var validationErr *jsonschema.ValidationError
+		if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), "additional properties") {
+			test.Errorf("expected additional properties schema validation error for %s, actual: %v", definition, err)
+		}
+	}
+
+	// type mismatch
+	if _, err := NewCheckRequest(strings.NewReader(`{"source": {"auth_engine": "token", "insecure": "true"}}`)); err == nil || !strings.Contains(err.Error(), "at '/source/insecure'") {
+		test.Errorf("expected schema validation error at /source/insecure, actual: %v", err)
+	}
+	// missing source
+	if _, err := NewOutRequest(strings.NewReader(`{"params": {}}`)); err == nil || !strings.Contains(err.Error(), "missing property 'source'") {
+		test.Errorf("expected schema validation error for missing source, actual: %v", err)
+	}
+}
+
+// test schema properties are synchronized with the json fields of the request structs
+func TestSchemaProperties(test *testing.T) {
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]any `json:"properties"`
+			OneOf      []struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"oneOf"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(Schema, &schema); err != nil {
+		test.Fatalf("the schema is not valid json: %s", err)
+	}
+
+	for definition, model := range map[string]any{
+		"checkRequest":    checkRequest{},
+		"inRequest":       inRequest{},
+		"outRequest":      outRequest{},
+		"source":          Source{},
+		"secretSource":    SecretSource{},
+		"compositeSecret": CompositeSecret{},
+		"inSecrets":       secrets{},
+		"inSecretPath":    secretPath{},
+		"outSecrets":      secretsPut{},
+		"secretCopy":      secretCopy{},
+	} {
+		schemaProperties := schema.Defs[definition].Properties
+		for _, oneOf := range schema.Defs[definition].OneOf {
+			if oneOf.Properties != nil {
+				schemaProperties = oneOf.Properties
+			}
+		}
+
+		modelType := reflect.TypeOf(model)
+		fields := []string{}
+		for index := range modelType.NumField() {
+			name, _, _ := strings.Cut(modelType.Field(index).Tag.Get("json"), ",")
+			if name != "-" {
+				fields = append(fields, name)
+			}
+		}
+		properties := []string{}
+		for property := range schemaProperties {
+			properties = append(properties, property)
+		}
+		slices.Sort(fields)
+		slices.Sort(properties)
+
+		if !slices.Equal(fields, properties) {
+			test.Errorf("the schema definition %s properties: %v, do not match the json fields: %v", definition, properties, fields)
+		}
+	}
+}
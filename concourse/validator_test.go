@@ -0,0 +1,36 @@
+package concourse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	// register the secret engine validator for request construction
+	_ "github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// test request constructors validate the secret engines with the registered validator
+func TestEngineValidator(test *testing.T) {
+	// composite secrets
+	for compositeJSON, expectedErr := range map[string]string{
+		`{"secrets": [{"engine": "aws", "mount": "aws", "path": "readonly"}]}`:                    "invalid composite secret engine",
+		`{"secrets": [{"engine": "kv1", "mount": "kv", "path": "bar"}]}`:                          "hmac key required for unversioned composite secret",
+		`{"hmac_key": "key", "secrets": [{"engine": "generic", "mount": "sys", "prefix": true}]}`: "prefix specified with unlistable engine",
+	} {
+		if _, err := concourse.NewCheckRequest(strings.NewReader(`{"source": ` + compositeJSON + `}`)); err == nil || err.Error() != expectedErr {
+			test.Errorf("expected check request error: %s, actual: %v", expectedErr, err)
+		}
+		if _, err := concourse.NewInRequest(strings.NewReader(`{"source": ` + compositeJSON + `}`)); err == nil || err.Error() != expectedErr {
+			test.Errorf("expected in request error: %s, actual: %v", expectedErr, err)
+		}
+	}
+
+	// dry runs of secrets
+	dryRunGeneric := strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"sys": {"engine": "generic", "dry_run": true, "secrets": {"policy/foo": {"policy": "bar"}}}}}`)
+	if _, err := concourse.NewOutRequest(dryRunGeneric); err == nil || err.Error() != "diff unsupported for secret engine" {
+		test.Errorf("expected error: diff unsupported for secret engine, actual: %v", err)
+	}
+	if _, err := concourse.NewOutRequest(strings.NewReader(`{"source": {"auth_engine": "token"}, "params": {"database": {"dry_run": true, "revoke": ["database/creds/readonly/abcd"]}}}`)); err != nil {
+		test.Errorf("expected revocation dry run without engine to be valid, actual error: %v", err)
+	}
+}
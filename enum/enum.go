@@ -2,7 +2,7 @@ package enum
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 	"slices"
 )
 
@@ -21,7 +21,7 @@ var authEngines []AuthEngine = []AuthEngine{AppRole, AWSIAM, KubernetesSA, Vault
 // authengine type conversion
 func (a AuthEngine) New() (AuthEngine, error) {
 	if !slices.Contains(authEngines, a) {
-		log.Printf("string %s could not be converted to AuthEngine enum", a)
+		slog.Error("string could not be converted to AuthEngine enum", "auth_engine", a)
 		return "", errors.New("invalid authengine enum")
 	}
 	return a, nil
@@ -44,14 +44,16 @@ const (
 	// static secret storage
 	KeyValue1 SecretEngine = "kv1"
 	KeyValue2 SecretEngine = "kv2"
+	// arbitrary logical read and write
+	Generic SecretEngine = "generic"
 )
 
-var secretEngines []SecretEngine = []SecretEngine{Database, AWS, Azure, Consul, Kubernetes, Nomad, RabbitMQ, SSH, Terraform, KeyValue1, KeyValue2}
+var secretEngines []SecretEngine = []SecretEngine{Database, AWS, Azure, Consul, Kubernetes, Nomad, RabbitMQ, SSH, Terraform, KeyValue1, KeyValue2, Generic}
 
 // secretengine type conversion
 func (s SecretEngine) New() (SecretEngine, error) {
 	if !slices.Contains(secretEngines, s) {
-		log.Printf("string %s could not be converted to SecretEngine enum", s)
+		slog.Error("string could not be converted to SecretEngine enum", "engine", s)
 		return "", errors.New("invalid secretengine enum")
 	}
 	return s, nil
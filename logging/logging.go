@@ -0,0 +1,67 @@
+// Package logging configures the structured logger of the resource with sensitive values masked in all output.
+package logging
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mschuchard/concourse-vault-resource/redact"
+)
+
+// output of the structured logger with sensitive values masked, and the configured level and format
+var (
+	output           io.Writer = redact.Writer(os.Stderr)
+	configuredLevel  string
+	configuredFormat string
+)
+
+// default to text format at info level until configured from source
+func init() {
+	Configure("", "")
+}
+
+// set output of the structured logger with sensitive values masked (typically os.Stderr because concourse reserves stdout for the step response), and retain the configured level and format
+func SetOutput(out io.Writer) {
+	output = redact.Writer(out)
+	Configure(configuredLevel, configuredFormat)
+}
+
+// configure the default structured logger with level (debug, info, warn, error) and format (text, json) where empty signifies info and text
+func Configure(level string, format string) error {
+	// determine level
+	var slogLevel slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		slogLevel = slog.LevelDebug
+	case "", "info":
+		slogLevel = slog.LevelInfo
+	case "warn":
+		slogLevel = slog.LevelWarn
+	case "error":
+		slogLevel = slog.LevelError
+	default:
+		slog.Error("the log level must be one of debug, info, warn, or error", "log_level", level)
+		return errors.New("invalid log level")
+	}
+
+	// determine format
+	handlerOptions := &slog.HandlerOptions{Level: slogLevel}
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = slog.NewTextHandler(output, handlerOptions)
+	case "json":
+		handler = slog.NewJSONHandler(output, handlerOptions)
+	default:
+		slog.Error("the log format must be one of text or json", "log_format", format)
+		return errors.New("invalid log format")
+	}
+
+	slog.SetDefault(slog.New(handler))
+	configuredLevel, configuredFormat = level, format
+
+	return nil
+}
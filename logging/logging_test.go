@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/redact"
+)
+
+// test structured logger level and format configuration
+func TestConfigure(test *testing.T) {
+	buffer := &bytes.Buffer{}
+	SetOutput(buffer)
+	defer func() {
+		Configure("", "")
+		SetOutput(os.Stderr)
+	}()
+
+	// json format with level filtering
+	if err := Configure("warn", "json"); err != nil {
+		test.Errorf("valid level and format returned error: %s", err)
+	}
+	redact.Register("loggedsecretvalue")
+	slog.Info("filtered message")
+	slog.Warn("retained message", "engine", "kv2", "token", "loggedsecretvalue")
+
+	entry := map[string]any{}
+	if err := json.Unmarshal(buffer.Bytes(), &entry); err != nil {
+		test.Fatalf("expected single json log entry, actual: %s", buffer.String())
+	}
+	if entry["msg"] != "retained message" || entry["level"] != "WARN" || entry["engine"] != "kv2" {
+		test.Errorf("unexpected json log entry: %v", entry)
+	}
+	if entry["token"] != redact.Mask {
+		test.Errorf("expected masked token in json log entry, actual: %v", entry["token"])
+	}
+
+	// text format and level retained after output reset
+	if err := Configure("DEBUG", "text"); err != nil {
+		test.Errorf("valid level and format returned error: %s", err)
+	}
+	buffer.Reset()
+	SetOutput(buffer)
+	slog.Debug("debug message", "path", "foo/bar")
+	if output := buffer.String(); !strings.Contains(output, "level=DEBUG") || !strings.Contains(output, `msg="debug message" path=foo/bar`) {
+		test.Errorf("unexpected text log entry: %s", output)
+	}
+
+	// secret values with characters escaped by the text format are masked
+	secretValue := "pa\"ss\\word\x01é"
+	redact.Register(secretValue)
+	buffer.Reset()
+	slog.Info("secret message", "value", secretValue, "error", errors.New("invalid value "+secretValue))
+	if output := buffer.String(); strings.Contains(output, "pa\\\"ss") || strings.Contains(output, "word\\x01") || strings.Count(output, redact.Mask) != 2 {
+		test.Errorf("text log entry did not mask the escaped secret value: %s", output)
+	}
+
+	// invalid level and format
+	if err := Configure("verbose", ""); err == nil || err.Error() != "invalid log level" {
+		test.Errorf("expected error: invalid log level, actual: %v", err)
+	}
+	if err := Configure("", "yaml"); err == nil || err.Error() != "invalid log format" {
+		test.Errorf("expected error: invalid log format, actual: %v", err)
+	}
+}
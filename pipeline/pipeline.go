@@ -0,0 +1,370 @@
+// Package pipeline validates the resources of this type in a Concourse pipeline, and their get and put steps, with the same validation as the check, in, and out steps.
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	// register the secret engine validator for request construction
+	_ "github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// image repository suffix of this resource type for detection of the resource types in a pipeline
+const imageRepository = "concourse-vault-resource"
+
+// pipeline value interpolated entirely from a var (e.g. "((vault_token))")
+var interpolatedVar = regexp.MustCompile(`^\(\([^()]+\)\)$`)
+
+// keys of a step whose values are never nested steps
+var nonStepKeys = []string{"config", "get_params", "params", "source", "vars"}
+
+// printer for schema validation messages
+var printer = message.NewPrinter(language.English)
+
+// Finding is a validation error for a resource or one of its steps at a line of the pipeline
+type Finding struct {
+	Line     int
+	Resource string
+	// check for the resource source, or the get or put step
+	Step    string
+	Message string
+}
+
+func (finding Finding) String() string {
+	return fmt.Sprintf("%d: resource %s %s: %s", finding.Line, finding.Resource, finding.Step, finding.Message)
+}
+
+// resource of this type in the pipeline
+type resource struct {
+	name   string
+	line   int
+	source *yaml.Node
+}
+
+// validate the source of every resource of the resource types (empty signifies detection by image repository), and the params of its get and put steps
+func Validate(pipelineYAML io.Reader, resourceTypes []string) ([]Finding, error) {
+	var document yaml.Node
+	if err := yaml.NewDecoder(pipelineYAML).Decode(&document); err != nil {
+		slog.Error("the pipeline could not be decoded from YAML", "error", err)
+		return nil, err
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		slog.Error("the pipeline is not a YAML mapping")
+		return nil, errors.New("invalid pipeline")
+	}
+	pipeline := document.Content[0]
+
+	// detect resource types by image repository if unspecified
+	if len(resourceTypes) == 0 {
+		for _, resourceType := range sequence(mappingValue(pipeline, "resource_types")) {
+			repository := scalar(mappingValue(mappingValue(resourceType, "source"), "repository"))
+			if strings.HasSuffix(repository, imageRepository) {
+				resourceTypes = append(resourceTypes, scalar(mappingValue(resourceType, "name")))
+			}
+		}
+	}
+
+	// collect resources of the resource types by name, and validate their sources with the check step validation
+	findings := []Finding{}
+	resources := map[string]resource{}
+	for _, resourceNode := range sequence(mappingValue(pipeline, "resources")) {
+		if !slices.Contains(resourceTypes, scalar(mappingValue(resourceNode, "type"))) {
+			continue
+		}
+
+		resource := resource{name: scalar(mappingValue(resourceNode, "name")), line: resourceNode.Line, source: mappingValue(resourceNode, "source")}
+		resources[resource.name] = resource
+		findings = append(findings, validateRequest(resource, "check", resourceNode.Line, nil)...)
+	}
+
+	// validate the get and put steps of the resources in all jobs
+	for _, job := range sequence(mappingValue(pipeline, "jobs")) {
+		findings = append(findings, validateSteps(job, resources)...)
+	}
+
+	// source findings are reported once for the resource rather than for each of its steps
+	uniqueFindings := []Finding{}
+	for _, finding := range findings {
+		if !slices.ContainsFunc(uniqueFindings, func(uniqueFinding Finding) bool {
+			return uniqueFinding.Resource == finding.Resource && uniqueFinding.Line == finding.Line && uniqueFinding.Message == finding.Message
+		}) {
+			uniqueFindings = append(uniqueFindings, finding)
+		}
+	}
+
+	return uniqueFindings, nil
+}
+
+// validate the get and put steps for the resources within the node and its nested steps
+func validateSteps(node *yaml.Node, resources map[string]resource) []Finding {
+	findings := []Finding{}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			findings = append(findings, validateSteps(item, resources)...)
+		}
+	case yaml.MappingNode:
+		for _, step := range []string{"get", "put"} {
+			name := scalar(mappingValue(node, step))
+			if len(name) == 0 {
+				continue
+			}
+			if resourceName := scalar(mappingValue(node, "resource")); len(resourceName) > 0 {
+				name = resourceName
+			}
+			resource, ok := resources[name]
+			if !ok {
+				continue
+			}
+
+			if step == "get" {
+				findings = append(findings, validateRequest(resource, "get", node.Line, mappingValue(node, "params"))...)
+			} else {
+				findings = append(findings, validateRequest(resource, "put", node.Line, mappingValue(node, "params"))...)
+				// the implicit get after the put step is executed with the get_params
+				if scalar(mappingValue(node, "no_get")) != "true" {
+					findings = append(findings, validateRequest(resource, "put implicit get", node.Line, mappingValue(node, "get_params"))...)
+				}
+			}
+		}
+
+		// nested steps (e.g. do, in_parallel, try, and hooks)
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			if !slices.Contains(nonStepKeys, node.Content[index].Value) {
+				findings = append(findings, validateSteps(node.Content[index+1], resources)...)
+			}
+		}
+	}
+
+	return findings
+}
+
+// validate the request of the step constructed from the resource source and the step params, and return findings at the lines of the errors
+func validateRequest(resource resource, step string, line int, params *yaml.Node) []Finding {
+	// request with key nodes of the request fields and copies of the value nodes from the pipeline
+	source := resource.source
+	if source == nil {
+		source = &yaml.Node{Kind: yaml.MappingNode, Line: resource.line}
+	}
+	request := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{{Kind: yaml.ScalarNode, Value: "source"}, cloneNode(source)}}
+	if params != nil {
+		request.Content = append(request.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "params"}, cloneNode(params))
+	}
+
+	for {
+		err := constructRequest(request, step)
+		if err == nil {
+			return nil
+		}
+
+		// schema validation errors are located at their line, and other errors at the line of the step
+		var validationErr *jsonschema.ValidationError
+		if !errors.As(err, &validationErr) {
+			return []Finding{{Line: line, Resource: resource.name, Step: step, Message: err.Error()}}
+		}
+		findings := []Finding{}
+		varNodes := []*yaml.Node{}
+		for _, cause := range validationCauses(validationErr) {
+			node := errorNode(request, cause)
+			// the type of a value interpolated from a var is unknown until the pipeline is set
+			if node != nil && node.Kind == yaml.ScalarNode && interpolatedVar.MatchString(node.Value) {
+				varNodes = append(varNodes, node)
+				continue
+			}
+			errorLine := line
+			if node != nil && node.Line > 0 {
+				errorLine = node.Line
+			}
+
+			findings = append(findings, Finding{
+				Line:     errorLine,
+				Resource: resource.name,
+				Step:     step,
+				Message:  fmt.Sprintf("at '/%s': %s", strings.Join(cause.InstanceLocation, "/"), cause.ErrorKind.LocalizedString(printer)),
+			})
+		}
+		if len(findings) > 0 || len(varNodes) == 0 {
+			return findings
+		}
+
+		// every schema validation error is for a var interpolated value, so remove those values and validate the remainder of the request
+		removeNodes(request, varNodes)
+	}
+}
+
+// convert the request to json, and construct it with the request constructor for the step
+func constructRequest(request *yaml.Node, step string) error {
+	var requestValue any
+	if err := request.Decode(&requestValue); err != nil {
+		return err
+	}
+	requestJSON, err := json.Marshal(requestValue)
+	if err != nil {
+		return err
+	}
+
+	switch step {
+	case "check":
+		_, err = concourse.NewCheckRequest(bytes.NewReader(requestJSON))
+	case "get", "put implicit get":
+		_, err = concourse.NewInRequest(bytes.NewReader(requestJSON))
+	case "put":
+		_, err = concourse.NewOutRequest(bytes.NewReader(requestJSON))
+	}
+
+	return err
+}
+
+// return the causes of the schema validation error, and of a failed oneOf or anyOf only for the single alternative of the same type
+func validationCauses(validationErr *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	switch validationErr.ErrorKind.(type) {
+	case *kind.OneOf, *kind.AnyOf:
+		alternatives := slices.DeleteFunc(slices.Clone(validationErr.Causes), func(cause *jsonschema.ValidationError) bool {
+			_, typeMismatch := cause.ErrorKind.(*kind.Type)
+			return typeMismatch
+		})
+		if len(alternatives) != 1 {
+			return []*jsonschema.ValidationError{validationErr}
+		}
+
+		return validationCauses(alternatives[0])
+	}
+	if len(validationErr.Causes) == 0 {
+		return []*jsonschema.ValidationError{validationErr}
+	}
+
+	causes := []*jsonschema.ValidationError{}
+	for _, cause := range validationErr.Causes {
+		causes = append(causes, validationCauses(cause)...)
+	}
+
+	return causes
+}
+
+// return the node of the schema validation error in the request, or nil if it is not found
+func errorNode(request *yaml.Node, validationErr *jsonschema.ValidationError) *yaml.Node {
+	node := request
+	for _, key := range validationErr.InstanceLocation {
+		if node = childNode(node, key, false); node == nil {
+			return nil
+		}
+	}
+
+	// locate the first additional property at its key
+	if additionalProperties, ok := validationErr.ErrorKind.(*kind.AdditionalProperties); ok && len(additionalProperties.Properties) > 0 {
+		if keyNode := childNode(node, additionalProperties.Properties[0], true); keyNode != nil {
+			node = keyNode
+		}
+	}
+
+	return node
+}
+
+// return the child node of a mapping by key (or the key node itself), or of a sequence by index
+func childNode(node *yaml.Node, key string, keyNode bool) *yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			if node.Content[index].Value == key {
+				if keyNode {
+					return node.Content[index]
+				}
+				return node.Content[index+1]
+			}
+		}
+	case yaml.SequenceNode:
+		var index int
+		if _, err := fmt.Sscan(key, &index); err == nil && index >= 0 && index < len(node.Content) {
+			return node.Content[index]
+		}
+	}
+
+	return nil
+}
+
+// return the value node of the mapping for the key, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind == yaml.SequenceNode {
+		return nil
+	}
+
+	return childNode(node, key, false)
+}
+
+// return the items of the sequence node, or nil
+func sequence(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	return node.Content
+}
+
+// return the value of the scalar node, or empty
+func scalar(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+
+	return node.Value
+}
+
+// return a deep copy of the node so that the pipeline is not modified
+func cloneNode(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	clone := *node
+	clone.Content = make([]*yaml.Node, len(node.Content))
+	for index, child := range node.Content {
+		clone.Content[index] = cloneNode(child)
+	}
+
+	return &clone
+}
+
+// remove the mapping values with their keys and the sequence items for the nodes
+func removeNodes(node *yaml.Node, nodes []*yaml.Node) {
+	content := []*yaml.Node{}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for index := 0; index+1 < len(node.Content); index += 2 {
+			if !slices.Contains(nodes, node.Content[index+1]) {
+				content = append(content, node.Content[index], node.Content[index+1])
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if !slices.Contains(nodes, item) {
+				content = append(content, item)
+			}
+		}
+	default:
+		return
+	}
+
+	node.Content = content
+	for _, child := range node.Content {
+		removeNodes(child, nodes)
+	}
+}
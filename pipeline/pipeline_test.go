@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testPipeline = `
+resource_types:
+- name: secrets
+  type: registry-image
+  source: {repository: custom/vault}
+resources:
+- name: vault
+  type: secrets
+  source:
+    auth_engine: token
+    concurrency: ((concurrency))
+    secret: {engine: kv2, mount: secret, path: foo/bar, lease_id: invalid}
+jobs:
+- name: job
+  plan:
+  - do:
+    - try:
+        get: vault
+        params: {secret: {engine: kv2, paths: [foo]}}
+  on_failure:
+    put: vault
+    params: {secret: {engine: kv2, revoke: [other/lease]}}
+`
+
+// test pipeline resource and step validation
+func TestValidate(test *testing.T) {
+	// resource type is not detected by image repository so nothing is validated
+	findings, err := Validate(strings.NewReader(testPipeline), nil)
+	if err != nil || len(findings) != 0 {
+		test.Errorf("expected no findings for undetected resource type, actual: %v, error: %v", findings, err)
+	}
+
+	// specified resource type with nested steps and hooks, and var interpolated values are not type validated
+	findings, err = Validate(strings.NewReader(testPipeline), []string{"secrets"})
+	if err != nil {
+		test.Error(err)
+	}
+	expectedFindings := []Finding{
+		{Line: 7, Resource: "vault", Step: "check", Message: "invalid lease id parameter"},
+		{Line: 18, Resource: "vault", Step: "get", Message: "dual secrets specified"},
+		{Line: 21, Resource: "vault", Step: "put", Message: "revoked lease outside of mount"},
+	}
+	if !slices.Equal(findings, expectedFindings) {
+		test.Errorf("expected findings: %v, actual: %v", expectedFindings, findings)
+	}
+	if expected := "7: resource vault check: invalid lease id parameter"; findings[0].String() != expected {
+		test.Errorf("expected finding string: %s, actual: %s", expected, findings[0].String())
+	}
+
+	// invalid pipelines
+	for _, pipelineYAML := range []string{"- not\n- a mapping", "jobs: [", ""} {
+		if _, err = Validate(strings.NewReader(pipelineYAML), nil); err == nil {
+			test.Errorf("expected error for invalid pipeline: %s", pipelineYAML)
+		}
+	}
+}
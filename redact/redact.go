@@ -0,0 +1,108 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// mask replacing sensitive values in log output
+const Mask = "[REDACTED]"
+
+// values shorter than this length are not masked because they would mask common words and numbers throughout the log output
+const minLength = 4
+
+// registry of sensitive values masked in log output
+var (
+	mutex  sync.RWMutex
+	values = map[string]bool{}
+)
+
+// register sensitive values (e.g. tokens and secret ids) to mask in log output
+func Register(sensitiveValues ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, value := range sensitiveValues {
+		if len(value) < minLength {
+			continue
+		}
+		values[value] = true
+
+		// errors from the vault api may echo request bodies with json escaped values
+		if escaped, err := json.Marshal(value); err == nil {
+			if escapedValue := string(escaped[1 : len(escaped)-1]); escapedValue != value {
+				values[escapedValue] = true
+			}
+		}
+		// the text log handler quotes values with go escaping
+		if quoted := strconv.Quote(value); quoted[1:len(quoted)-1] != value {
+			values[quoted[1:len(quoted)-1]] = true
+		}
+	}
+}
+
+// register all values in a secret value retrieved from or written to vault including nested values
+func RegisterSecretValue(secretValue map[string]any) {
+	for _, value := range secretValue {
+		registerAny(value)
+	}
+}
+
+func registerAny(value any) {
+	switch typedValue := value.(type) {
+	case string:
+		Register(typedValue)
+	case map[string]any:
+		RegisterSecretValue(typedValue)
+	case []any:
+		for _, element := range typedValue {
+			registerAny(element)
+		}
+	case nil, bool:
+	default:
+		Register(fmt.Sprint(typedValue))
+	}
+}
+
+// mask all registered sensitive values in message
+func String(message string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	if len(values) == 0 {
+		return message
+	}
+
+	// mask longer values first so that values containing other values are fully masked
+	sensitiveValues := slices.SortedFunc(maps.Keys(values), func(first string, second string) int { return len(second) - len(first) })
+	for _, value := range sensitiveValues {
+		message = strings.ReplaceAll(message, value, Mask)
+	}
+
+	return message
+}
+
+// writer masking all registered sensitive values in each write (e.g. each log message)
+type writer struct {
+	out io.Writer
+}
+
+// return writer wrapping out that masks all registered sensitive values (typically for the structured logger output)
+func Writer(out io.Writer) io.Writer {
+	return &writer{out: out}
+}
+
+func (writer *writer) Write(message []byte) (int, error) {
+	if _, err := io.WriteString(writer.out, String(string(message))); err != nil {
+		return 0, err
+	}
+
+	// report the unmasked length as written so that callers do not treat masking as a short write
+	return len(message), nil
+}
@@ -0,0 +1,47 @@
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+)
+
+// test sensitive value registration and masking
+func TestString(test *testing.T) {
+	Register("hvs.abcdefghijklmnop", "abc", "", `pass"word`)
+	RegisterSecretValue(map[string]any{"password": "supersecret", "nested": map[string]any{"list": []any{"nestedsecret", 123456}}, "enabled": true})
+
+	for message, expected := range map[string]string{
+		"token hvs.abcdefghijklmnop is invalid":                    "token " + Mask + " is invalid",
+		"value supersecret and nestedsecret and 123456":            "value " + Mask + " and " + Mask + " and " + Mask,
+		`request body {"password":"pass\"word"} was rejected`:      `request body {"password":"` + Mask + `"} was rejected`,
+		"short values like abc and true are not masked":            "short values like abc and true are not masked",
+		"values containing values supersecretsupersecret are gone": "values containing values " + Mask + Mask + " are gone",
+	} {
+		if actual := String(message); actual != expected {
+			test.Errorf("expected masked message: %s, actual: %s", expected, actual)
+		}
+	}
+
+	// longer values containing shorter values are fully masked
+	Register("secret", "topsecretvalue")
+	if actual := String("topsecretvalue"); actual != Mask {
+		test.Errorf("expected fully masked value, actual: %s", actual)
+	}
+}
+
+// test log output masking including wrapped errors
+func TestWriter(test *testing.T) {
+	Register("wrappedsecret")
+
+	buffer := &bytes.Buffer{}
+	logger := log.New(Writer(buffer), "", 0)
+	logger.Print(fmt.Errorf("operation failed: %w", errors.New("invalid value wrappedsecret")))
+
+	if output := buffer.String(); strings.Contains(output, "wrappedsecret") || output != "operation failed: invalid value "+Mask+"\n" {
+		test.Errorf("log output was not masked: %s", output)
+	}
+}
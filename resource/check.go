@@ -0,0 +1,123 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// GET for secret versions as determined by the secret engine
+func RunCheck(ctx context.Context, stdin io.Reader, stdout io.Writer, clientFactory ClientFactory) error {
+	// step start time for duration
+	start := time.Now()
+
+	// configure logging from the request source before the request is validated
+	stdin, err := configureRequestLogging(stdin)
+	if err != nil {
+		return err
+	}
+
+	// initialize checkRequest and secretSource
+	checkRequest, err := concourse.NewCheckRequest(stdin)
+	if err != nil {
+		slog.Error("unable to construct request for check step", "error", err)
+		return vault.NewError(vault.ErrInvalidConfig, err)
+	}
+	secretSource := checkRequest.Source.Secret
+
+	// return immediately if secret unspecified in source
+	if secretSource == (concourse.SecretSource{}) && len(checkRequest.Source.Secrets) == 0 {
+		// dummy check response
+		dummyResponse := concourse.NewCheckResponse([]concourse.Version{{Version: "0"}})
+		// format checkResponse into json
+		if err := json.NewEncoder(stdout).Encode(&dummyResponse); err != nil {
+			slog.Error("unable to marshal dummy check response struct to JSON", "error", err)
+			return err
+		}
+
+		slog.Info("source does not contain a secret, and concourse version will be set to value '0'")
+		slog.Info("check step completed", "duration", time.Since(start))
+
+		return nil
+	}
+
+	// initialize vault client from concourse source
+	vaultClient, err := clientFactory(ctx, checkRequest.Source)
+	if err != nil {
+		slog.Error("vault client failed to initialize during check", "error", err)
+		return err
+	}
+
+	// composite secrets in source are checked as a single composite version
+	if len(checkRequest.Source.Secrets) > 0 {
+		compositeVersion, err := compositeCheck(ctx, vaultClient, checkRequest.Source)
+		if err != nil {
+			slog.Error("composite version could not be retrieved for the composite secrets in source", "error", err)
+			return err
+		}
+
+		// format checkResponse into json
+		checkResponse := concourse.NewCheckResponse([]concourse.Version{compositeVersion})
+		if err := json.NewEncoder(stdout).Encode(&checkResponse); err != nil {
+			slog.Error("unable to marshal check response struct to JSON", "error", err)
+			return err
+		}
+
+		slog.Info("check step completed", "duration", time.Since(start))
+
+		return nil
+	}
+
+	// initialize vault secret from concourse source params and invoke constructor
+	secret, err := vault.NewVaultSecret(secretSource.Engine, secretSource.Mount, secretSource.Path)
+	if err != nil {
+		slog.Error("failed to construct secret from Concourse source parameters", "engine", secretSource.Engine, "mount", secretSource.Mount, "path", secretSource.Path, "error", err)
+		return err
+	}
+
+	// abort if the step was cancelled
+	if err := ctx.Err(); err != nil {
+		slog.Error("check step cancelled before secret versions were retrieved", "error", err)
+		return err
+	}
+
+	// retrieve versions for secret from input version through current version
+	checkOptions := vault.CheckOptions{Lease: vault.Lease{ID: secretSource.LeaseId}, DigestKey: []byte(checkRequest.Source.HMACKey)}
+	if len(secretSource.Increment) > 0 {
+		if checkOptions.Lease.Increment, err = time.ParseDuration(secretSource.Increment); err != nil {
+			slog.Error("the lease increment is not a valid duration", "increment", secretSource.Increment, "error", err)
+			return err
+		}
+	}
+	secretVersions, err := secret.Check(ctx, vaultClient, checkRequest.Version.Version, checkOptions)
+	if err != nil {
+		slog.Error("versions could not be retrieved for secret", "engine", secretSource.Engine, "mount", secretSource.Mount, "path", secretSource.Path, "error", err)
+		return err
+	}
+	versions := []concourse.Version{}
+	for _, secretVersion := range secretVersions {
+		version := concourse.Version{Version: secretVersion.Version}
+		if !secretVersion.CreatedTime.IsZero() {
+			version.CreatedTime = secretVersion.CreatedTime.UTC().Format(time.RFC3339Nano)
+		}
+		versions = append(versions, version)
+	}
+
+	// input secret version to constructed response
+	checkResponse := concourse.NewCheckResponse(versions)
+
+	// format checkResponse into json
+	if err := json.NewEncoder(stdout).Encode(&checkResponse); err != nil {
+		slog.Error("unable to marshal check response struct to JSON", "error", err)
+		return err
+	}
+
+	slog.Info("check step completed", "duration", time.Since(start))
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+func TestRunCheck(test *testing.T) {
+	// deliver test pipeline file content as stdin the same as actual pipeline execution
+	stdin, err := util.FixtureFile("../cmd/check/fixtures/token_kv.json")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer stdin.Close()
+	stdout := &bytes.Buffer{}
+
+	if err := RunCheck(context.Background(), stdin, stdout, clientFactory); err != nil {
+		test.Errorf("check step failed: %s", err)
+	}
+	if !strings.HasPrefix(stdout.String(), `[{"created_time":"`) || !strings.HasSuffix(stdout.String(), `","version":"1"}]`+"\n") {
+		test.Errorf("check step response was unexpected: %s", stdout.String())
+	}
+
+	// source without secret returns dummy version without constructing client
+	stdout.Reset()
+	if err := RunCheck(context.Background(), strings.NewReader(`{"source":{"address":"http://localhost:8200","token":"abcdefghijklmnopqrstuvwxyz09"}}`), stdout, failingClientFactory); err != nil {
+		test.Errorf("check step without source secret failed: %s", err)
+	}
+	if stdout.String() != "[{\"version\":\"0\"}]\n" {
+		test.Errorf("check step dummy response was unexpected: %s", stdout.String())
+	}
+
+	// kv1 secret versioned by digest consistently with in step
+	stdout.Reset()
+	kv1Source := `{"source":{"address":"` + util.VaultAddress + `","auth_engine":"token","token":"` + util.VaultToken + `","hmac_key":"hmackey","secret":{"engine":"kv1","mount":"` + util.KV1Mount + `","path":"` + util.KVPath + `"}}}`
+	if err := RunCheck(context.Background(), strings.NewReader(kv1Source), stdout, clientFactory); err != nil {
+		test.Errorf("check step for kv1 secret failed: %s", err)
+	}
+	digestVersion, _ := vault.DigestVersion(map[string]any{util.KVKey: util.KVValue}, []byte("hmackey"))
+	if stdout.String() != `[{"version":"`+digestVersion+`"}]`+"\n" {
+		test.Errorf("check step kv1 digest response was unexpected: %s", stdout.String())
+	}
+	inStdout := &bytes.Buffer{}
+	if err := RunIn(context.Background(), strings.NewReader(kv1Source), inStdout, test.TempDir(), clientFactory); err != nil || !strings.Contains(inStdout.String(), `"version":{"`+util.KV1Mount+`-`+util.KVPath+`":"`+digestVersion+`"}`) {
+		test.Errorf("in step kv1 digest response was unexpected: %s, error: %v", inStdout.String(), err)
+	}
+
+	// kv2 secret without input version returns only the latest version as with the first check of a resource
+	stdout.Reset()
+	if err := RunCheck(context.Background(), strings.NewReader(`{"source":{"address":"`+util.VaultAddress+`","auth_engine":"token","token":"`+util.VaultToken+`","secret":{"engine":"kv2","mount":"`+util.KV2Mount+`","path":"`+util.KVPath+`"}}}`), stdout, clientFactory); err != nil {
+		test.Errorf("check step for kv2 secret without input version failed: %s", err)
+	}
+	if versions := []concourse.Version{}; json.Unmarshal(stdout.Bytes(), &versions) != nil || len(versions) != 1 {
+		test.Errorf("check step without input version did not return only the latest version: %s", stdout.String())
+	}
+
+	// invalid request
+	if err := RunCheck(context.Background(), strings.NewReader("{"), stdout, clientFactory); err == nil {
+		test.Error("check step did not fail on invalid request")
+	}
+
+	// invalid log level
+	if err := RunCheck(context.Background(), strings.NewReader(`{"source":{"auth_engine":"token","token":"hvs.abcdefghijklmnopqrstuvwx","log_level":"verbose"}}`), stdout, clientFactory); err == nil || err.Error() != "invalid log level" {
+		test.Errorf("expected error: invalid log level, actual: %v", err)
+	}
+
+	// unauthorized token
+	if err := RunCheck(context.Background(), strings.NewReader(`{"source":{"address":"`+util.VaultAddress+`","auth_engine":"token","token":"hvs.unauthorizedtoken","secret":{"engine":"kv2","mount":"`+util.KV2Mount+`","path":"`+util.KVPath+`"}}}`), stdout, clientFactory); !errors.Is(err, vault.ErrPermissionDenied) {
+		test.Errorf("expected permission denied error, actual: %v", err)
+	}
+
+	// client factory failure
+	stdin, _ = util.FixtureFile("../cmd/check/fixtures/token_kv.json")
+	defer stdin.Close()
+	if err := RunCheck(context.Background(), stdin, stdout, failingClientFactory); err == nil || err.Error() != "client factory failure" {
+		test.Errorf("check step did not return client factory error: %v", err)
+	}
+
+	// cancelled context
+	stdin, _ = util.FixtureFile("../cmd/check/fixtures/token_kv.json")
+	defer stdin.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := RunCheck(ctx, stdin, stdout, clientFactory); !errors.Is(err, context.Canceled) {
+		test.Errorf("check step did not return context cancellation: %v", err)
+	}
+}
+
+func TestRunCheckComposite(test *testing.T) {
+	// composite secrets with kv2 prefix and kv1 path
+	if _, err := util.VaultClient.KVv2(util.KV2Mount).Put(context.Background(), "composite/app/db", map[string]any{util.KVKey: util.KVValue}); err != nil {
+		test.Fatal(err)
+	}
+	compositeSource := `{"source":{"address":"` + util.VaultAddress + `","auth_engine":"token","token":"` + util.VaultToken + `","hmac_key":"hmackey","secrets":[{"engine":"kv2","mount":"` + util.KV2Mount + `","path":"composite","prefix":true},{"engine":"kv1","mount":"` + util.KV1Mount + `","path":"` + util.KVPath + `"}]}}`
+
+	stdout := &bytes.Buffer{}
+	if err := RunCheck(context.Background(), strings.NewReader(compositeSource), stdout, clientFactory); err != nil {
+		test.Fatalf("check step for composite secrets failed: %s", err)
+	}
+	var versions []concourse.Version
+	if err := json.Unmarshal(stdout.Bytes(), &versions); err != nil || len(versions) != 1 {
+		test.Fatalf("check step composite response was unexpected: %s, error: %v", stdout.String(), err)
+	}
+	digestVersion, _ := vault.DigestVersion(map[string]any{util.KVKey: util.KVValue}, []byte("hmackey"))
+	kv2Identifier := util.KV2Mount + "-composite/app/db"
+	kv2Version := versions[0].Secrets[kv2Identifier]
+	if len(versions[0].Version) != 64 || len(kv2Version) == 0 || versions[0].Secrets[util.KV1Mount+"-"+util.KVPath] != digestVersion {
+		test.Errorf("check step composite version breakdown was unexpected: %v", versions[0])
+	}
+
+	// composite version changes with any secret version
+	if _, err := util.VaultClient.KVv2(util.KV2Mount).Put(context.Background(), "composite/app/db", map[string]any{util.KVKey: "changed"}); err != nil {
+		test.Fatal(err)
+	}
+	stdout.Reset()
+	if err := RunCheck(context.Background(), strings.NewReader(compositeSource), stdout, clientFactory); err != nil {
+		test.Errorf("check step for changed composite secrets failed: %s", err)
+	}
+	var changedVersions []concourse.Version
+	if err := json.Unmarshal(stdout.Bytes(), &changedVersions); err != nil || len(changedVersions) != 1 || changedVersions[0].Version == versions[0].Version || changedVersions[0].Secrets[kv2Identifier] == kv2Version {
+		test.Errorf("check step composite version did not change with secret version: %s, error: %v", stdout.String(), err)
+	}
+
+	// in step reads the composite secrets at the checked versions
+	versionJSON, _ := json.Marshal(versions[0])
+	inStdout := &bytes.Buffer{}
+	inDir := test.TempDir()
+	if err := RunIn(context.Background(), strings.NewReader(strings.TrimSuffix(compositeSource, "}")+`,"version":`+string(versionJSON)+`}`), inStdout, inDir, clientFactory); err != nil {
+		test.Fatalf("in step for composite secrets failed: %s", err)
+	}
+	if !strings.Contains(inStdout.String(), `"`+kv2Identifier+`":"`+kv2Version+`"`) {
+		test.Errorf("in step composite response was unexpected: %s", inStdout.String())
+	}
+	if secretValues, err := os.ReadFile(inDir + "/vault.json"); err != nil || !strings.Contains(string(secretValues), `"`+kv2Identifier+`":{"`+util.KVKey+`":"`+util.KVValue+`"}`) {
+		test.Errorf("in step did not read composite secret at checked version: %s, error: %v", secretValues, err)
+	}
+
+	// invalid composite secret
+	if err := RunCheck(context.Background(), strings.NewReader(`{"source":{"secrets":[{"engine":"aws","mount":"aws","path":"readonly"}]}}`), stdout, clientFactory); err == nil || err.Error() != "invalid composite secret engine" {
+		test.Errorf("expected error: invalid composite secret engine, actual: %v", err)
+	}
+}
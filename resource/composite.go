@@ -0,0 +1,72 @@
+package resource
+
+import (
+	"context"
+	"log/slog"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// expands composite secrets in source into individual secrets with prefixes listed recursively
+func expandCompositeSecrets(ctx context.Context, client *vaultapi.Client, compositeSecrets []concourse.CompositeSecret) ([]paramsSecret, error) {
+	secrets := []paramsSecret{}
+	for _, compositeSecret := range compositeSecrets {
+		if !compositeSecret.Prefix {
+			secrets = append(secrets, paramsSecret{engine: compositeSecret.Engine, mount: compositeSecret.Mount, path: compositeSecret.Path})
+			continue
+		}
+
+		paths, err := vault.ListSecretPaths(ctx, client, compositeSecret.Engine, compositeSecret.Mount, compositeSecret.Path)
+		if err != nil {
+			slog.Error("the secrets at prefix could not be listed", "engine", compositeSecret.Engine, "mount", compositeSecret.Mount, "prefix", compositeSecret.Path, "error", err)
+			return nil, err
+		}
+		for _, path := range paths {
+			secrets = append(secrets, paramsSecret{engine: compositeSecret.Engine, mount: compositeSecret.Mount, path: path})
+		}
+	}
+
+	return secrets, nil
+}
+
+// current composite version of the composite secrets in source with the per secret version breakdown
+func compositeCheck(ctx context.Context, client *vaultapi.Client, source concourse.Source) (concourse.Version, error) {
+	secrets, err := expandCompositeSecrets(ctx, client, source.Secrets)
+	if err != nil {
+		return concourse.Version{}, err
+	}
+
+	// kv2 secrets are versioned by version number, and kv1 and generic secrets by digest
+	checkOptions := vault.CheckOptions{DigestKey: []byte(source.HMACKey)}
+	secretVersions := map[string]string{}
+	for _, compositeSecret := range secrets {
+		// abort if the step was cancelled
+		if err := ctx.Err(); err != nil {
+			slog.Error("check step cancelled before composite secret versions were retrieved", "error", err)
+			return concourse.Version{}, err
+		}
+
+		secret, err := vault.NewVaultSecret(compositeSecret.engine, compositeSecret.mount, compositeSecret.path)
+		if err != nil {
+			slog.Error("failed to construct secret from Concourse source composite secrets", "engine", compositeSecret.engine, "mount", compositeSecret.mount, "path", compositeSecret.path, "error", err)
+			return concourse.Version{}, err
+		}
+		// only the current version is returned without an input version
+		versions, err := secret.Check(ctx, client, "", checkOptions)
+		if err != nil {
+			slog.Error("version could not be retrieved for composite secret", "engine", compositeSecret.engine, "mount", compositeSecret.mount, "path", compositeSecret.path, "error", err)
+			return concourse.Version{}, err
+		}
+		secretVersions[compositeSecret.mount+"-"+compositeSecret.path] = versions[len(versions)-1].Version
+	}
+
+	version, err := vault.CompositeVersion(secretVersions)
+	if err != nil {
+		return concourse.Version{}, err
+	}
+
+	return concourse.Version{Version: version, Secrets: secretVersions}, nil
+}
@@ -0,0 +1,308 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// writes inResponse.Metadata marshalled to json to file at /opt/resource/vault.json
+func SecretsToJSONFile(filePath string, secretValues concourse.SecretValues) error {
+	// marshal secretValues into json data
+	secretsData, err := json.Marshal(secretValues)
+	if err != nil {
+		slog.Error("unable to marshal SecretValues struct to json data", "error", err)
+		return err
+	}
+	// write secrets to file at /opt/resource/vault.json
+	secretsFile := filePath + "/vault.json"
+	if err = os.WriteFile(secretsFile, secretsData, 0o600); err != nil {
+		slog.Error("error writing secrets to destination file", "file_path", secretsFile, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// writes metadata marshalled to json to file at /opt/resource/metadata.json so that subsequent steps can access lease ids
+func MetadataToJSONFile(filePath string, metadata []concourse.MetadataEntry) error {
+	// marshal metadata into json data
+	metadataData, err := json.Marshal(metadata)
+	if err != nil {
+		slog.Error("unable to marshal metadata to json data", "error", err)
+		return err
+	}
+	// write metadata to file at /opt/resource/metadata.json
+	metadataFile := filePath + "/metadata.json"
+	if err = os.WriteFile(metadataFile, metadataData, 0o600); err != nil {
+		slog.Error("error writing metadata to destination file", "file_path", metadataFile, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// returns lease ids within mount from metadata file written by a get step and relative to the build input directory
+func MetadataFileLeaseIDs(dirPath string, metadataPath string, mount string) ([]string, error) {
+	// read and unmarshal metadata file
+	metadataData, err := readLocalFile(dirPath, metadataPath)
+	if err != nil {
+		slog.Error("unable to read metadata file", "file_path", metadataPath, "error", err)
+		return nil, err
+	}
+	var metadata []concourse.MetadataEntry
+	if err = json.Unmarshal(metadataData, &metadata); err != nil {
+		slog.Error("the metadata file is not a get step metadata file", "file_path", metadataPath, "error", err)
+		return nil, err
+	}
+
+	// collect non-empty lease ids within mount
+	leaseIds := []string{}
+	for _, entry := range metadata {
+		if strings.HasSuffix(entry.Name, "-LeaseID") && strings.HasPrefix(entry.Value, mount+"/") {
+			leaseIds = append(leaseIds, entry.Value)
+		}
+	}
+
+	return leaseIds, nil
+}
+
+// reads the file at the path relative to and within the build input directory, and rejects paths and symlinks resolving outside of it
+func readLocalFile(dirPath string, filePath string) ([]byte, error) {
+	if !filepath.IsLocal(filePath) {
+		slog.Error("the file path must be relative to and within the build input directory", "file_path", filePath)
+		return nil, errors.New("non-local file path")
+	}
+
+	root, err := os.OpenRoot(dirPath)
+	if err != nil {
+		slog.Error("unable to open the build input directory", "dir_path", dirPath, "error", err)
+		return nil, err
+	}
+	defer root.Close()
+
+	return root.ReadFile(filePath)
+}
+
+// secret value form sourcing content from a file in the build input directory
+type fileValue struct {
+	FromFile     string `json:"from_file"`
+	FromJSONFile string `json:"from_json_file"`
+	Base64       bool   `json:"base64"`
+}
+
+// resolves from_file and from_json_file value forms in secretValue to the contents of files relative to the build input directory
+func FileSecretValue(dirPath string, secretValue map[string]any) (map[string]any, error) {
+	resolvedValue := make(map[string]any, len(secretValue))
+
+	for key, value := range secretValue {
+		// literal values are assigned as-is
+		valueForm, ok := value.(map[string]any)
+		if !ok || (valueForm["from_file"] == nil && valueForm["from_json_file"] == nil) {
+			resolvedValue[key] = value
+			continue
+		}
+
+		// re-decode the value form strictly to validate its schema
+		valueFormJSON, err := json.Marshal(valueForm)
+		if err != nil {
+			slog.Error("unable to marshal the value form", "key", key, "error", err)
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(valueFormJSON))
+		decoder.DisallowUnknownFields()
+		var fileValue fileValue
+		if err = decoder.Decode(&fileValue); err != nil {
+			slog.Error("the value form must contain only one of from_file or from_json_file, and optionally base64", "key", key)
+			return nil, err
+		}
+		if len(fileValue.FromFile) > 0 && len(fileValue.FromJSONFile) > 0 {
+			slog.Error("from_file and from_json_file are mutually exclusive", "key", key)
+			return nil, errors.New("multiple file value forms")
+		}
+		if fileValue.Base64 && len(fileValue.FromJSONFile) > 0 {
+			slog.Error("base64 encoding is only supported with from_file", "key", key)
+			return nil, errors.New("base64 with from_json_file")
+		}
+
+		// read file content
+		filePath := fileValue.FromFile + fileValue.FromJSONFile
+		content, err := readLocalFile(dirPath, filePath)
+		if err != nil {
+			slog.Error("unable to read the file", "file_path", filePath, "key", key, "error", err)
+			return nil, err
+		}
+
+		// assign file content as string, base64 encoded string, or decoded json value
+		if len(fileValue.FromJSONFile) > 0 {
+			var jsonValue any
+			if err = json.Unmarshal(content, &jsonValue); err != nil {
+				slog.Error("the file does not contain valid JSON", "file_path", filePath, "key", key, "error", err)
+				return nil, err
+			}
+			resolvedValue[key] = jsonValue
+		} else if fileValue.Base64 {
+			resolvedValue[key] = base64.StdEncoding.EncodeToString(content)
+		} else {
+			resolvedValue[key] = string(content)
+		}
+	}
+
+	return resolvedValue, nil
+}
+
+// selects and renames keys in secretValue (empty keys signifies all keys)
+func ShapeSecretValue(secretValue map[string]any, keys []string, rename map[string]string) (map[string]any, error) {
+	// select keys from allow-list
+	shapedValue := make(map[string]any, len(secretValue))
+	if len(keys) == 0 {
+		maps.Copy(shapedValue, secretValue)
+	} else {
+		for _, key := range keys {
+			value, ok := secretValue[key]
+			if !ok {
+				slog.Error("the selected key does not exist in the secret", "key", key)
+				return nil, errors.New("selected key not found")
+			}
+			shapedValue[key] = value
+		}
+	}
+
+	// rename keys with removal before assignment so that keys can be swapped
+	renamedValue := maps.Clone(shapedValue)
+	for key := range rename {
+		if _, ok := shapedValue[key]; !ok {
+			slog.Error("the renamed key does not exist in the selected secret keys", "key", key)
+			return nil, errors.New("renamed key not found")
+		}
+		delete(renamedValue, key)
+	}
+	// renamed keys must not overwrite selected keys that are not renamed, or each other
+	for key, newKey := range rename {
+		if _, ok := renamedValue[newKey]; ok {
+			slog.Error("the renamed key collides with another selected or renamed key", "key", key, "new_key", newKey)
+			return nil, errors.New("renamed key collision")
+		}
+		renamedValue[newKey] = shapedValue[key]
+	}
+
+	return renamedValue, nil
+}
+
+// flattens nested objects in secretValue into top-level keys joined with underscores (e.g. db.password to db_password)
+func FlattenSecretValue(secretValue map[string]any) (map[string]any, error) {
+	flattenedValue := make(map[string]any, len(secretValue))
+	if err := flattenInto(flattenedValue, "", secretValue); err != nil {
+		return nil, err
+	}
+
+	return flattenedValue, nil
+}
+
+// recursively assign nested values to flattenedValue with key prefix
+func flattenInto(flattenedValue map[string]any, prefix string, secretValue map[string]any) error {
+	// sorted keys for deterministic collision errors
+	for _, key := range slices.Sorted(maps.Keys(secretValue)) {
+		flattenedKey := prefix + key
+
+		// recurse into nested non-empty objects
+		if nestedValue, ok := secretValue[key].(map[string]any); ok && len(nestedValue) > 0 {
+			if err := flattenInto(flattenedValue, flattenedKey+"_", nestedValue); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, ok := flattenedValue[flattenedKey]; ok {
+			slog.Error("the flattened key collides with another key in the secret", "key", flattenedKey)
+			return errors.New("duplicate flattened key")
+		}
+		flattenedValue[flattenedKey] = secretValue[key]
+	}
+
+	return nil
+}
+
+// converts Vault secret metadata information to Concourse metadata
+func VaultToConcourseMetadata(prefix string, secretMetadata vault.Metadata) []concourse.MetadataEntry {
+	// return vault metadata lease id, lease duration, and renewable as concourse metadata entries
+	metadata := []concourse.MetadataEntry{
+		{
+			Name:  prefix + "-LeaseID",
+			Value: secretMetadata.LeaseID,
+		},
+		{
+			Name:  prefix + "-LeaseDuration",
+			Value: secretMetadata.LeaseDuration.String(),
+		},
+		{
+			Name:  prefix + "-Renewable",
+			Value: strconv.FormatBool(secretMetadata.Renewable),
+		},
+	}
+	// flag secret values that were unchanged and not written
+	if secretMetadata.Unchanged {
+		metadata = append(metadata, concourse.MetadataEntry{Name: prefix + "-Unchanged", Value: "true"})
+	}
+
+	return metadata
+}
+
+// converts the key diff of a dry run to Concourse metadata with key names only
+func DryRunToConcourseMetadata(prefix string, diff vault.SecretValueDiff) []concourse.MetadataEntry {
+	return []concourse.MetadataEntry{
+		{
+			Name:  prefix + "-DryRun",
+			Value: "true",
+		},
+		{
+			Name:  prefix + "-AddedKeys",
+			Value: strings.Join(diff.Added, ","),
+		},
+		{
+			Name:  prefix + "-ChangedKeys",
+			Value: strings.Join(diff.Changed, ","),
+		},
+		{
+			Name:  prefix + "-RemovedKeys",
+			Value: strings.Join(diff.Removed, ","),
+		},
+	}
+}
+
+// exit code and reason for each kind of step failure in order of precedence when several secret operations fail
+var exitStatuses = []struct {
+	kind   error
+	code   int
+	reason string
+}{
+	{vault.ErrInvalidConfig, 2, "invalid configuration"},
+	{vault.ErrAuthFailure, 3, "Vault authentication failure"},
+	{vault.ErrPermissionDenied, 4, "Vault permission denied"},
+	{vault.ErrSealed, 5, "Vault sealed"},
+	{vault.ErrUnavailable, 6, "Vault unavailable"},
+	{vault.ErrNotFound, 7, "Vault secret not found"},
+	{vault.ErrVersionMissing, 8, "Vault secret version missing"},
+}
+
+// returns the distinct exit code and reason for the kind of step failure, so that permission and configuration problems are distinguishable from outages
+func ExitStatus(err error) (int, string) {
+	for _, exitStatus := range exitStatuses {
+		if errors.Is(err, exitStatus.kind) {
+			return exitStatus.code, exitStatus.reason
+		}
+	}
+
+	return 1, "unclassified failure"
+}
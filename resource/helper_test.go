@@ -0,0 +1,229 @@
+package resource
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// minimum coverage testing for helper functions
+func TestSecretsToJSONFile(test *testing.T) {
+	secretValues := concourse.SecretValues{"secretValue": {"key": "value"}}
+	if err := SecretsToJSONFile(".", secretValues); err != nil {
+		test.Error(err)
+	}
+	defer os.Remove("./vault.json")
+}
+
+func TestMetadataFileLeaseIDs(test *testing.T) {
+	dirPath := test.TempDir()
+	os.Mkdir(dirPath+"/vault", 0o700)
+	metadata := []concourse.MetadataEntry{
+		{Name: "database-readonly-LeaseID", Value: "database/creds/readonly/abcd"},
+		{Name: "database-readonly-Renewable", Value: "true"},
+		{Name: "aws-readonly-LeaseID", Value: "aws/creds/readonly/efgh"},
+		{Name: "secret-foo/bar-LeaseID", Value: ""},
+	}
+	if err := MetadataToJSONFile(dirPath+"/vault", metadata); err != nil {
+		test.Error(err)
+	}
+
+	// lease ids are filtered by mount
+	leaseIds, err := MetadataFileLeaseIDs(dirPath, "vault/metadata.json", "database")
+	if err != nil || !slices.Equal(leaseIds, []string{"database/creds/readonly/abcd"}) {
+		test.Errorf("unexpected lease ids from metadata file: %v, error: %v", leaseIds, err)
+	}
+
+	// test errors
+	if _, err = MetadataFileLeaseIDs(dirPath, "../metadata.json", "database"); err == nil || err.Error() != "non-local file path" {
+		test.Errorf("expected error: non-local file path, actual: %v", err)
+	}
+	// symlink escaping the build input directory
+	os.Symlink(filepath.Dir(dirPath), dirPath+"/escape")
+	if _, err = MetadataFileLeaseIDs(dirPath, "escape/"+filepath.Base(dirPath)+"/vault/metadata.json", "database"); err == nil {
+		test.Error("metadata file through symlink outside build input directory did not error")
+	}
+	if _, err = MetadataFileLeaseIDs(dirPath, "vault/nonexistent.json", "database"); err == nil {
+		test.Error("nonexistent metadata file did not error")
+	}
+}
+
+func TestFileSecretValue(test *testing.T) {
+	dirPath := test.TempDir()
+	os.Mkdir(dirPath+"/artifact", 0o700)
+	os.WriteFile(dirPath+"/artifact/id_rsa", []byte("private key"), 0o600)
+	os.WriteFile(dirPath+"/artifact/config.json", []byte(`{"foo":"bar"}`), 0o600)
+
+	secretValue, err := FileSecretValue(dirPath, map[string]any{
+		"literal":    "value",
+		"nested":     map[string]any{"key": "value"},
+		"file":       map[string]any{"from_file": "artifact/id_rsa"},
+		"base64file": map[string]any{"from_file": "artifact/id_rsa", "base64": true},
+		"jsonfile":   map[string]any{"from_json_file": "artifact/config.json"},
+	})
+	if err != nil {
+		test.Error("secret value failed to resolve from files")
+		test.Error(err)
+	}
+
+	expectedSecretValue := map[string]any{
+		"literal":    "value",
+		"nested":     map[string]any{"key": "value"},
+		"file":       "private key",
+		"base64file": "cHJpdmF0ZSBrZXk=",
+		"jsonfile":   map[string]any{"foo": "bar"},
+	}
+	if !reflect.DeepEqual(secretValue, expectedSecretValue) {
+		test.Error("secret value resolved from files returned unexpected value")
+		test.Errorf("expected value: %v", expectedSecretValue)
+		test.Errorf("actual value: %v", secretValue)
+	}
+
+	// test errors
+	if _, err = FileSecretValue(dirPath, map[string]any{"key": map[string]any{"from_file": "../id_rsa"}}); err == nil || err.Error() != "non-local file path" {
+		test.Errorf("expected error: non-local file path, actual: %v", err)
+	}
+	// symlink escaping the build input directory
+	outsideDir := test.TempDir()
+	os.WriteFile(outsideDir+"/id_rsa", []byte("outside key"), 0o600)
+	os.Symlink(outsideDir, dirPath+"/creds")
+	if _, err = FileSecretValue(dirPath, map[string]any{"key": map[string]any{"from_file": "creds/id_rsa"}}); err == nil {
+		test.Error("file through symlink outside build input directory did not error")
+	}
+	if _, err = FileSecretValue(dirPath, map[string]any{"key": map[string]any{"from_file": "a", "from_json_file": "b"}}); err == nil || err.Error() != "multiple file value forms" {
+		test.Errorf("expected error: multiple file value forms, actual: %v", err)
+	}
+	if _, err = FileSecretValue(dirPath, map[string]any{"key": map[string]any{"from_json_file": "artifact/id_rsa"}}); err == nil {
+		test.Error("expected error for file with invalid JSON content")
+	}
+	if _, err = FileSecretValue(dirPath, map[string]any{"key": map[string]any{"from_file": "artifact/id_rsa", "foo": "bar"}}); err == nil {
+		test.Error("expected error for value form with unknown field")
+	}
+}
+
+func TestShapeSecretValue(test *testing.T) {
+	secretValue := map[string]any{"foo": "bar", "baz": "bat", "other": "value"}
+
+	shapedValue, err := ShapeSecretValue(secretValue, []string{"foo", "baz"}, map[string]string{"foo": "baz", "baz": "foo"})
+	if err != nil {
+		test.Error("secret value failed to shape")
+		test.Error(err)
+	}
+	expectedValue := map[string]any{"baz": "bar", "foo": "bat"}
+	if !reflect.DeepEqual(shapedValue, expectedValue) {
+		test.Error("shaped secret value returned unexpected value")
+		test.Errorf("expected value: %v", expectedValue)
+		test.Errorf("actual value: %v", shapedValue)
+	}
+
+	if shapedValue, _ = ShapeSecretValue(secretValue, nil, nil); !reflect.DeepEqual(shapedValue, secretValue) {
+		test.Errorf("expected unshaped secret value: %v, actual: %v", secretValue, shapedValue)
+	}
+
+	// test errors
+	if _, err = ShapeSecretValue(secretValue, []string{"missing"}, nil); err == nil || err.Error() != "selected key not found" {
+		test.Errorf("expected error: selected key not found, actual: %v", err)
+	}
+	if _, err = ShapeSecretValue(secretValue, []string{"foo"}, map[string]string{"baz": "BAZ"}); err == nil || err.Error() != "renamed key not found" {
+		test.Errorf("expected error: renamed key not found, actual: %v", err)
+	}
+	for _, rename := range []map[string]string{{"foo": "baz"}, {"foo": "new", "baz": "new"}} {
+		if _, err = ShapeSecretValue(secretValue, []string{"foo", "baz"}, rename); err == nil || err.Error() != "renamed key collision" {
+			test.Errorf("expected error: renamed key collision for rename %v, actual: %v", rename, err)
+		}
+	}
+}
+
+func TestFlattenSecretValue(test *testing.T) {
+	secretValue := map[string]any{"db": map[string]any{"password": "secret", "host": map[string]any{"name": "localhost"}}, "user": "admin", "empty": map[string]any{}}
+	flattenedValue, err := FlattenSecretValue(secretValue)
+	if err != nil {
+		test.Error("secret value failed to flatten")
+		test.Error(err)
+	}
+	expectedValue := map[string]any{"db_password": "secret", "db_host_name": "localhost", "user": "admin", "empty": map[string]any{}}
+	if !reflect.DeepEqual(flattenedValue, expectedValue) {
+		test.Errorf("expected flattened value: %v, actual: %v", expectedValue, flattenedValue)
+	}
+
+	// test errors
+	if _, err = FlattenSecretValue(map[string]any{"db": map[string]any{"password": "secret"}, "db_password": "other"}); err == nil || err.Error() != "duplicate flattened key" {
+		test.Errorf("expected error: duplicate flattened key, actual: %v", err)
+	}
+}
+
+func TestVaultToConcourseMetadata(test *testing.T) {
+	duration, _ := time.ParseDuration("65535s")
+	secretMetadata := vault.Metadata{
+		LeaseID:       "abcdefg12345",
+		LeaseDuration: duration,
+		Renewable:     false,
+	}
+	secretPath := "secret-foo/bar"
+
+	concourseMetadata := VaultToConcourseMetadata(secretPath, secretMetadata)
+	expectedConcourseMetadata := []concourse.MetadataEntry{
+		{
+			Name:  secretPath + "-LeaseID",
+			Value: secretMetadata.LeaseID,
+		},
+		{
+			Name:  secretPath + "-LeaseDuration",
+			Value: secretMetadata.LeaseDuration.String(),
+		},
+		{
+			Name:  secretPath + "-Renewable",
+			Value: strconv.FormatBool(secretMetadata.Renewable),
+		},
+	}
+
+	if !slices.Equal(expectedConcourseMetadata, concourseMetadata) {
+		test.Error("vault to concourse metadata conversion returned unexpected value")
+		test.Errorf("expected value: %v", expectedConcourseMetadata)
+		test.Errorf("actual value: %v", concourseMetadata)
+	}
+
+	// unchanged secret value is flagged
+	secretMetadata.Unchanged = true
+	expectedConcourseMetadata = append(expectedConcourseMetadata, concourse.MetadataEntry{Name: secretPath + "-Unchanged", Value: "true"})
+	if concourseMetadata = VaultToConcourseMetadata(secretPath, secretMetadata); !slices.Equal(expectedConcourseMetadata, concourseMetadata) {
+		test.Errorf("expected unchanged value metadata: %v, actual: %v", expectedConcourseMetadata, concourseMetadata)
+	}
+}
+
+func TestExitStatus(test *testing.T) {
+	permissionErr := vault.NewError(vault.ErrPermissionDenied, errors.New("permission denied"))
+
+	for expectedCode, err := range map[int]error{
+		1: errors.New("unclassified"),
+		2: errors.Join(permissionErr, vault.NewError(vault.ErrInvalidConfig, errors.New("invalid secret engine"))),
+		4: permissionErr,
+		6: vault.NewError(vault.ErrUnavailable, errors.New("vault standby")),
+		8: vault.NewError(vault.ErrVersionMissing, errors.New("secret version does not exist")),
+	} {
+		if code, reason := ExitStatus(err); code != expectedCode || len(reason) == 0 {
+			test.Errorf("expected exit code: %d, actual: %d, reason: %s", expectedCode, code, reason)
+		}
+	}
+}
+
+func TestDryRunToConcourseMetadata(test *testing.T) {
+	diff := vault.SecretValueDiff{Added: []string{"bar", "foo"}, Changed: []string{}, Removed: []string{"baz"}}
+	expectedConcourseMetadata := []concourse.MetadataEntry{
+		{Name: "secret-foo/bar-DryRun", Value: "true"},
+		{Name: "secret-foo/bar-AddedKeys", Value: "bar,foo"},
+		{Name: "secret-foo/bar-ChangedKeys", Value: ""},
+		{Name: "secret-foo/bar-RemovedKeys", Value: "baz"},
+	}
+	if concourseMetadata := DryRunToConcourseMetadata("secret-foo/bar", diff); !slices.Equal(concourseMetadata, expectedConcourseMetadata) {
+		test.Errorf("expected dry run metadata: %v, actual: %v", expectedConcourseMetadata, concourseMetadata)
+	}
+}
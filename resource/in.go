@@ -0,0 +1,250 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// GET and primary
+func RunIn(ctx context.Context, stdin io.Reader, stdout io.Writer, dir string, clientFactory ClientFactory) error {
+	// step start time for duration
+	start := time.Now()
+
+	// configure logging from the request source before the request is validated
+	stdin, err := configureRequestLogging(stdin)
+	if err != nil {
+		return err
+	}
+
+	// initialize request from concourse pipeline and response storing secret values
+	inRequest, err := concourse.NewInRequest(stdin)
+	if err != nil {
+		slog.Error("unable to construct request for in/get step", "error", err)
+		return vault.NewError(vault.ErrInvalidConfig, err)
+	}
+	inResponse := concourse.NewResponse()
+	// initialize vault client from concourse source
+	vaultClient, err := clientFactory(ctx, inRequest.Source)
+	if err != nil {
+		slog.Error("vault client failed to initialize during in/get", "error", err)
+		return err
+	}
+
+	// initialize secretValues to store aggregated retrieved secrets and secretSource for efficiency
+	var secretMetadata vault.Metadata
+	secretValues := concourse.SecretValues{}
+	secretSource := inRequest.Source.Secret
+
+	// read secrets from params, or composite secrets in source
+	if secretSource == (concourse.SecretSource{}) {
+		// collect params secrets in deterministic mount and path order
+		paramsSecrets := []paramsSecret{}
+		// composite secrets in source are read at the checked versions if no params
+		if inRequest.Params == nil {
+			if paramsSecrets, err = expandCompositeSecrets(ctx, vaultClient, inRequest.Source.Secrets); err != nil {
+				slog.Error("composite secrets in source could not be expanded during in/get", "error", err)
+				return err
+			}
+			for index, compositeSecret := range paramsSecrets {
+				if !vault.DigestVersioned(compositeSecret.engine) {
+					paramsSecrets[index].version = inRequest.Version.Secrets[compositeSecret.mount+"-"+compositeSecret.path]
+				}
+			}
+		}
+		for _, mount := range slices.Sorted(maps.Keys(inRequest.Params)) {
+			secretParams := inRequest.Params[mount]
+			for _, secretPath := range secretParams.Paths {
+				keys, rename, flatten := secretParams.Shape(secretPath)
+				paramsSecrets = append(paramsSecrets, paramsSecret{engine: secretParams.Engine, mount: mount, path: secretPath.Path, version: secretPath.Version, keys: keys, rename: rename, flatten: flatten})
+			}
+		}
+
+		// read params secrets with bounded concurrency, and then aggregate results in the same order as collected
+		for index, result := range readParamsSecrets(ctx, vaultClient, paramsSecrets, inRequest.Source.Concurrency) {
+			// abort remaining aggregation if the step was cancelled
+			if errors.Is(result.err, context.Canceled) || errors.Is(result.err, context.DeadlineExceeded) {
+				slog.Error("in/get step cancelled before all secret operations completed", "error", result.err)
+				return errors.Join(err, result.err)
+			}
+			// join error into collection
+			err = errors.Join(err, result.err)
+			// secret construction failed so there is no value, version, or metadata
+			if result.skipped {
+				continue
+			}
+
+			// declare identifier
+			identifier := paramsSecrets[index].mount + "-" + paramsSecrets[index].path
+			// assign the secret values for the given path and version
+			secretValues[identifier] = result.value
+			inResponse.Version[identifier] = result.metadata.Version
+			// convert rawSecret to concourse metadata and concat with metadata
+			inResponse.Metadata = slices.Concat(inResponse.Metadata, VaultToConcourseMetadata(identifier, result.metadata))
+		}
+	} else { // read secret from source
+		// initialize vault secret from concourse source params
+		secret, nestedErr := vault.NewVaultSecret(secretSource.Engine, secretSource.Mount, secretSource.Path)
+		// on failure log the issue and then attempt next secret
+		if nestedErr != nil {
+			slog.Error("failed to construct secret from Concourse source parameters, and the secret will not be read", "engine", secretSource.Engine, "mount", secretSource.Mount, "path", secretSource.Path, "error", nestedErr)
+
+			// join error into collection
+			err = errors.Join(err, nestedErr)
+		} else {
+			// declare identifier and rawSecret
+			identifier := secretSource.Mount + "-" + secretSource.Path
+			// unversioned secrets are read without the input version which is their digest
+			version := inRequest.Version.Version
+			digestVersioned := vault.DigestVersioned(secretSource.Engine)
+			if digestVersioned {
+				version = ""
+			}
+			// return and assign the secret values for the given path
+			secretValues[identifier], secretMetadata, nestedErr = secret.SecretValue(ctx, vaultClient, version)
+			// unversioned secrets are versioned by digest consistent with check
+			if nestedErr == nil && len(inRequest.Source.HMACKey) > 0 && digestVersioned {
+				secretMetadata.Version, nestedErr = vault.DigestVersion(secretValues[identifier], []byte(inRequest.Source.HMACKey))
+			}
+			inResponse.Version[identifier] = secretMetadata.Version
+
+			if nestedErr != nil {
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+			} else {
+				// convert rawSecret to concourse metadata and concat with metadata
+				inResponse.Metadata = slices.Concat(inResponse.Metadata, VaultToConcourseMetadata(identifier, secretMetadata))
+			}
+		}
+	}
+
+	// fatally exit if any secret Read operation failed
+	if err != nil {
+		slog.Error("one or more attempted secret Read operations failed", "error", err)
+		return err
+	}
+
+	// write marshalled metadata to file at /opt/resource/vault.json
+	err = SecretsToJSONFile(dir, secretValues)
+	if err != nil {
+		slog.Error("failed to output secrets in json format to file", "error", err)
+		return err
+	}
+
+	// write marshalled metadata with lease ids to file at /opt/resource/metadata.json
+	if err = MetadataToJSONFile(dir, inResponse.Metadata); err != nil {
+		slog.Error("failed to output metadata in json format to file", "error", err)
+		return err
+	}
+
+	// marshal, encode, and pass inResponse json as output to concourse
+	if err = json.NewEncoder(stdout).Encode(inResponse); err != nil {
+		slog.Error("unable to marshal in response struct to JSON", "error", err)
+		return err
+	}
+
+	slog.Info("in/get step completed", "duration", time.Since(start))
+
+	return nil
+}
+
+// secret specified in in/get params
+type paramsSecret struct {
+	engine  enum.SecretEngine
+	mount   string
+	path    string
+	version string
+	// key selection, renaming, and flattening of the secret value
+	keys    []string
+	rename  map[string]string
+	flatten bool
+}
+
+// result of reading a secret specified in in/get params
+type paramsSecretResult struct {
+	value    map[string]any
+	metadata vault.Metadata
+	err      error
+	// secret was not read because it could not be constructed or the step was cancelled
+	skipped bool
+}
+
+// reads params secrets with at most concurrency simultaneous reads, and returns results in the same order as the input secrets
+func readParamsSecrets(ctx context.Context, client *vaultapi.Client, paramsSecrets []paramsSecret, concurrency int) []paramsSecretResult {
+	// default to sequential reads
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// each goroutine writes only to its own index so results require no additional synchronization
+	results := make([]paramsSecretResult, len(paramsSecrets))
+	semaphore := make(chan struct{}, concurrency)
+	var waitGroup sync.WaitGroup
+
+	for index, paramsSecret := range paramsSecrets {
+		// acquire a slot or stop scheduling reads if the step was cancelled
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			results[index] = paramsSecretResult{err: ctx.Err(), skipped: true}
+			continue
+		}
+
+		waitGroup.Go(func() {
+			defer func() { <-semaphore }()
+			results[index] = readParamsSecret(ctx, client, paramsSecret)
+		})
+	}
+	waitGroup.Wait()
+
+	return results
+}
+
+// reads a single params secret
+func readParamsSecret(ctx context.Context, client *vaultapi.Client, paramsSecret paramsSecret) paramsSecretResult {
+	// do not begin a read if the step was cancelled
+	if err := ctx.Err(); err != nil {
+		return paramsSecretResult{err: err, skipped: true}
+	}
+
+	// initialize vault secret from concourse params
+	secret, err := vault.NewVaultSecret(paramsSecret.engine, paramsSecret.mount, paramsSecret.path)
+	// on failure log the issue and then attempt next secret
+	if err != nil {
+		slog.Error("failed to construct secret from Concourse parameters, and the secret will not be read", "engine", paramsSecret.engine, "mount", paramsSecret.mount, "path", paramsSecret.path, "error", err)
+
+		return paramsSecretResult{err: err, skipped: true}
+	}
+
+	// return the secret values for the given path and version (empty signifies latest)
+	value, metadata, err := secret.SecretValue(ctx, client, paramsSecret.version)
+	if err != nil {
+		return paramsSecretResult{value: value, metadata: metadata, err: err}
+	}
+
+	// flatten, and then select and rename, the secret value keys
+	if paramsSecret.flatten {
+		value, err = FlattenSecretValue(value)
+	}
+	if err == nil {
+		value, err = ShapeSecretValue(value, paramsSecret.keys, paramsSecret.rename)
+	}
+	if err != nil {
+		slog.Error("failed to select, rename, or flatten the keys for the secret", "engine", paramsSecret.engine, "mount", paramsSecret.mount, "path", paramsSecret.path, "error", err)
+		err = vault.NewError(vault.ErrInvalidConfig, err)
+	}
+
+	return paramsSecretResult{value: value, metadata: metadata, err: err}
+}
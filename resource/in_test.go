@@ -0,0 +1,139 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+	"github.com/mschuchard/concourse-vault-resource/logging"
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+func TestRunIn(test *testing.T) {
+	// params secrets and source secret with expected vault.json contents
+	for secretKey, secretsContents := range map[string]string{
+		"params": `{"kv-foo/bar":{"PASSWORD":"supersecret"},"secret-bar/baz":{"password":"supersecret"},"secret-foo/bar":{"other_password":"ultrasecret","password":"supersecret"}}`,
+		"source": `{"secret-foo/bar":{"other_password":"ultrasecret","password":"supersecret"}}`,
+	} {
+		// deliver test pipeline file content as stdin the same as actual pipeline execution
+		stdin, err := util.FixtureFile("../cmd/in/fixtures/token_kv_" + secretKey + ".json")
+		if err != nil {
+			test.Fatal(err)
+		}
+		defer stdin.Close()
+		stdout := &bytes.Buffer{}
+		dir := test.TempDir()
+
+		if err := RunIn(context.Background(), stdin, stdout, dir, clientFactory); err != nil {
+			test.Errorf("in step failed for %s: %s", secretKey, err)
+		}
+		if !strings.Contains(stdout.String(), `"version":`) {
+			test.Errorf("in step response for %s was unexpected: %s", secretKey, stdout.String())
+		}
+
+		// verify metadata.json output
+		if _, err := os.Stat(dir + "/metadata.json"); err != nil {
+			test.Errorf("metadata.json was not written for %s: %s", secretKey, err)
+		}
+
+		// verify vault.json output
+		secretsFile, _ := os.ReadFile(dir + "/vault.json")
+		if string(secretsFile) != secretsContents {
+			test.Errorf("vault.json did not contain expected secrets data for %s", secretKey)
+			test.Errorf("actual file contents: %s", secretsFile)
+			test.Errorf("expected file contents: %s", secretsContents)
+		}
+	}
+
+	// kv1 source secret with digest input version is read without the version
+	logOutput := &bytes.Buffer{}
+	logging.SetOutput(logOutput)
+	stdout := &bytes.Buffer{}
+	digestRequest := `{"source":{"address":"` + util.VaultAddress + `","auth_engine":"token","token":"` + util.VaultToken + `","hmac_key":"key","secret":{"engine":"kv1","mount":"kv","path":"foo/bar"}},"version":{"version":"abcdef0123456789"}}`
+	err := RunIn(context.Background(), strings.NewReader(digestRequest), stdout, test.TempDir(), clientFactory)
+	logging.SetOutput(os.Stderr)
+	if err != nil {
+		test.Errorf("in step failed for kv1 digest version: %s", err)
+	}
+	if strings.Contains(logOutput.String(), "versions cannot be used") || !strings.Contains(stdout.String(), `"kv-foo/bar":"`) || strings.Contains(stdout.String(), "abcdef0123456789") {
+		test.Errorf("kv1 secret was read with the digest input version, response: %s, log: %s", stdout.String(), logOutput.String())
+	}
+
+	// invalid request
+	if err := RunIn(context.Background(), strings.NewReader("{"), &bytes.Buffer{}, test.TempDir(), clientFactory); err == nil {
+		test.Error("in step did not fail on invalid request")
+	}
+
+	// client factory failure
+	stdin, _ := util.FixtureFile("../cmd/in/fixtures/token_kv_params.json")
+	defer stdin.Close()
+	if err := RunIn(context.Background(), stdin, &bytes.Buffer{}, test.TempDir(), failingClientFactory); err == nil || err.Error() != "client factory failure" {
+		test.Errorf("in step did not return client factory error: %v", err)
+	}
+
+	// cancelled context
+	stdin, _ = util.FixtureFile("../cmd/in/fixtures/token_kv_params.json")
+	defer stdin.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := RunIn(ctx, stdin, &bytes.Buffer{}, test.TempDir(), clientFactory); !errors.Is(err, context.Canceled) {
+		test.Errorf("in step did not return context cancellation: %v", err)
+	}
+}
+
+func TestReadParamsSecrets(test *testing.T) {
+	// secrets in order with an invalid engine and a nonexistent path interleaved
+	paramsSecrets := []paramsSecret{
+		{engine: enum.KeyValue2, mount: util.KV2Mount, path: util.KVPath, version: "1"},
+		{engine: "invalid", mount: util.KV2Mount, path: util.KVPath},
+		{engine: enum.KeyValue1, mount: util.KV1Mount, path: util.KVPath},
+		{engine: enum.KeyValue2, mount: util.KV2Mount, path: "does/not/exist"},
+		{engine: enum.KeyValue2, mount: util.KV2Mount, path: util.KVPath},
+	}
+
+	// key selection, renaming, and flattening
+	shapeResult := readParamsSecret(context.Background(), util.VaultClient, paramsSecret{engine: enum.KeyValue2, mount: util.KV2Mount, path: util.KVPath, keys: []string{util.KVKey}, rename: map[string]string{util.KVKey: "DB_PASSWORD"}, flatten: true})
+	if shapeResult.err != nil || len(shapeResult.value) != 1 || shapeResult.value["DB_PASSWORD"] != util.KVValue {
+		test.Errorf("unexpected shaped secret result: %v", shapeResult)
+	}
+	if shapeResult = readParamsSecret(context.Background(), util.VaultClient, paramsSecret{engine: enum.KeyValue2, mount: util.KV2Mount, path: util.KVPath, keys: []string{"missing"}}); shapeResult.err == nil || shapeResult.err.Error() != "selected key not found" {
+		test.Errorf("expected error: selected key not found, actual: %v", shapeResult.err)
+	}
+
+	for _, concurrency := range []int{0, 1, 3, 10} {
+		results := readParamsSecrets(context.Background(), util.VaultClient, paramsSecrets, concurrency)
+		if len(results) != len(paramsSecrets) {
+			test.Fatalf("expected %d results with concurrency %d, actual: %d", len(paramsSecrets), concurrency, len(results))
+		}
+
+		// results are in the same order as the input secrets
+		if results[0].err != nil || results[0].value[util.KVKey] != util.KVValue || results[0].metadata.Version != "1" {
+			test.Errorf("unexpected kv2 versioned result with concurrency %d: %v", concurrency, results[0])
+		}
+		if results[1].err == nil || results[1].err.Error() != "invalid secret engine" || !results[1].skipped {
+			test.Errorf("unexpected invalid engine result with concurrency %d: %v", concurrency, results[1])
+		}
+		if results[2].err != nil || results[2].value[util.KVKey] != util.KVValue {
+			test.Errorf("unexpected kv1 result with concurrency %d: %v", concurrency, results[2])
+		}
+		if results[3].err == nil || results[3].skipped {
+			test.Errorf("unexpected nonexistent secret result with concurrency %d: %v", concurrency, results[3])
+		}
+		if results[4].err != nil || results[4].value[util.KVKey] != util.KVValue {
+			test.Errorf("unexpected kv2 latest result with concurrency %d: %v", concurrency, results[4])
+		}
+	}
+
+	// cancelled context skips every read
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for index, result := range readParamsSecrets(ctx, util.VaultClient, paramsSecrets, 2) {
+		if !errors.Is(result.err, context.Canceled) || !result.skipped {
+			test.Errorf("expected cancelled and skipped result at index %d, actual: %v", index, result)
+		}
+	}
+}
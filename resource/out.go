@@ -0,0 +1,272 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// PUT/POST
+func RunOut(ctx context.Context, stdin io.Reader, stdout io.Writer, dir string, clientFactory ClientFactory) error {
+	// step start time for duration
+	start := time.Now()
+
+	// configure logging from the request source before the request is validated
+	stdin, err := configureRequestLogging(stdin)
+	if err != nil {
+		return err
+	}
+
+	// initialize request from concourse pipeline and response to satisfy concourse requirement
+	outRequest, err := concourse.NewOutRequest(stdin)
+	if err != nil {
+		slog.Error("unable to construct request for out/put step", "error", err)
+		return vault.NewError(vault.ErrInvalidConfig, err)
+	}
+	outResponse := concourse.NewResponse()
+	// initialize vault client from concourse source
+	vaultClient, err := clientFactory(ctx, outRequest.Source)
+	if err != nil {
+		slog.Error("vault client failed to initialize during out/put", "error", err)
+		return err
+	}
+
+	// perform secrets operations
+	for mount, secretParams := range outRequest.Params {
+		// utilize the vault namespace for the mount if specified
+		mountClient := vaultClient
+		if len(secretParams.Namespace) > 0 {
+			mountClient = vaultClient.WithNamespace(secretParams.Namespace)
+		}
+
+		// iterate through secrets and assign each path to each vault secret path, and write each secret value to the path
+		for secretPath, secretValue := range secretParams.Secrets {
+			// abort remaining secret operations if the step was cancelled
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				slog.Error("out/put step cancelled before all secret operations completed", "error", ctxErr)
+				return errors.Join(err, ctxErr)
+			}
+			// initialize vault secret from concourse params
+			secret, nestedErr := vault.NewVaultSecret(secretParams.Engine, mount, secretPath)
+			// on failure log the issue and then attempt next secret
+			if nestedErr != nil {
+				slog.Error("failed to construct secret from Concourse parameters, and the secret will not be created or updated", "engine", secretParams.Engine, "mount", mount, "path", secretPath, "error", nestedErr)
+
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+
+				// attempt next secret immediately
+				continue
+			}
+			// resolve secret values sourced from files in the build input directory
+			secretValue, nestedErr := FileSecretValue(dir, secretValue)
+			if nestedErr != nil {
+				nestedErr = vault.NewError(vault.ErrInvalidConfig, nestedErr)
+				slog.Error("failed to resolve secret values from files in the build input directory, and the secret will not be created or updated", "engine", secretParams.Engine, "mount", mount, "path", secretPath, "error", nestedErr)
+
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+
+				// attempt next secret immediately
+				continue
+			}
+			// resolve secret values generated server-side by vault (or only validate the values to generate if dry run)
+			var generatedKeys []string
+			if secretParams.DryRun {
+				generatedKeys, nestedErr = vault.GeneratedKeys(secretValue)
+			} else {
+				secretValue, nestedErr = vault.GenerateSecretValue(ctx, mountClient, secretValue)
+			}
+			if nestedErr != nil {
+				slog.Error("failed to generate secret values with Vault, and the secret will not be created or updated", "engine", secretParams.Engine, "mount", mount, "path", secretPath, "error", nestedErr)
+
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+
+				// attempt next secret immediately
+				continue
+			}
+			// declare identifier and rawSecret
+			identifier := mount + "-" + secretPath
+			// write the secret value to the path for the specified mount and engine (or compare if dry run)
+			version, secretMetadata, nestedErr := populateSecret(ctx, mountClient, secret, identifier, secretValue, generatedKeys, secretParams.Patch, secretParams.DryRun)
+			outResponse.Version[identifier] = version
+
+			if nestedErr != nil {
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+			} else {
+				// concat secret metadata with metadata
+				outResponse.Metadata = slices.Concat(outResponse.Metadata, secretMetadata)
+			}
+		}
+
+		// iterate through secret copies and write each source secret value to each destination path
+		for secretPath, secretCopy := range secretParams.Copy {
+			// abort remaining secret operations if the step was cancelled
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				slog.Error("out/put step cancelled before all secret operations completed", "error", ctxErr)
+				return errors.Join(err, ctxErr)
+			}
+			// initialize source and destination vault secrets from concourse params
+			sourceSecret, nestedErr := vault.NewVaultSecret(secretCopy.Engine, secretCopy.Mount, secretCopy.Path)
+			secret, destErr := vault.NewVaultSecret(secretParams.Engine, mount, secretPath)
+			// on failure log the issue and then attempt next secret
+			if nestedErr = errors.Join(nestedErr, destErr); nestedErr != nil {
+				slog.Error("failed to construct secrets from Concourse copy parameters, and the secret will not be copied", "engine", secretParams.Engine, "mount", mount, "path", secretPath, "error", nestedErr)
+
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+
+				// attempt next secret immediately
+				continue
+			}
+
+			// utilize the vault namespace for the source secret if specified
+			sourceClient := vaultClient
+			if len(secretCopy.Namespace) > 0 {
+				sourceClient = vaultClient.WithNamespace(secretCopy.Namespace)
+			}
+
+			// read the source secret value for the specified version (empty signifies latest), and select and rename its keys
+			secretValue, _, nestedErr := sourceSecret.SecretValue(ctx, sourceClient, secretCopy.Version.String())
+			if nestedErr == nil {
+				secretValue, nestedErr = ShapeSecretValue(secretValue, secretCopy.Keys, secretCopy.Rename)
+			}
+			if nestedErr != nil {
+				slog.Error("failed to read the copy source secret, and the secret will not be copied", "source_engine", secretCopy.Engine, "source_mount", secretCopy.Mount, "source_path", secretCopy.Path, "engine", secretParams.Engine, "mount", mount, "path", secretPath, "error", nestedErr)
+
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+
+				// attempt next secret immediately
+				continue
+			}
+
+			// declare identifier
+			identifier := mount + "-" + secretPath
+			// write the source secret value to the destination path for the specified mount and engine (or compare if dry run)
+			version, secretMetadata, nestedErr := populateSecret(ctx, mountClient, secret, identifier, secretValue, nil, secretParams.Patch, secretParams.DryRun)
+			outResponse.Version[identifier] = version
+
+			if nestedErr != nil {
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+			} else {
+				// concat secret metadata with metadata
+				outResponse.Metadata = slices.Concat(outResponse.Metadata, secretMetadata)
+			}
+		}
+
+		// collect lease ids to revoke from params and get step metadata file
+		leaseIds := secretParams.Revoke
+		if len(secretParams.RevokeFromFile) > 0 {
+			fileLeaseIds, nestedErr := MetadataFileLeaseIDs(dir, secretParams.RevokeFromFile, mount)
+			if nestedErr != nil {
+				slog.Error("the leases in metadata file will not be revoked", "mount", mount, "file_path", secretParams.RevokeFromFile, "error", nestedErr)
+
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+			}
+			leaseIds = slices.Concat(leaseIds, fileLeaseIds)
+		}
+
+		// report leases and lease prefixes that would be revoked if dry run
+		if secretParams.DryRun {
+			for _, leaseId := range leaseIds {
+				outResponse.Metadata = append(outResponse.Metadata, concourse.MetadataEntry{Name: mount + "-DryRunRevokedLeaseID", Value: leaseId})
+			}
+			for _, prefix := range secretParams.RevokePrefix {
+				outResponse.Metadata = append(outResponse.Metadata, concourse.MetadataEntry{Name: mount + "-DryRunRevokedPrefix", Value: prefix})
+			}
+			continue
+		}
+
+		// revoke leases and lease prefixes
+		for _, leaseId := range leaseIds {
+			if nestedErr := vault.RevokeLease(ctx, mountClient, leaseId); nestedErr != nil {
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+			} else {
+				outResponse.Metadata = append(outResponse.Metadata, concourse.MetadataEntry{Name: mount + "-RevokedLeaseID", Value: leaseId})
+			}
+		}
+		for _, prefix := range secretParams.RevokePrefix {
+			if nestedErr := vault.RevokeLeasePrefix(ctx, mountClient, prefix); nestedErr != nil {
+				// join error into collection
+				err = errors.Join(err, nestedErr)
+			} else {
+				outResponse.Metadata = append(outResponse.Metadata, concourse.MetadataEntry{Name: mount + "-RevokedPrefix", Value: prefix})
+			}
+		}
+	}
+
+	// fatally exit if any secret Write or Revoke operation failed
+	if err != nil {
+		slog.Error("one or more attempted secret Create/Update/Revoke operations failed", "error", err)
+		return err
+	}
+
+	// format outResponse into json
+	if err = json.NewEncoder(stdout).Encode(outResponse); err != nil {
+		slog.Error("unable to marshal out response struct to JSON", "error", err)
+		return err
+	}
+
+	slog.Info("out/put step completed", "duration", time.Since(start))
+
+	return nil
+}
+
+// secret that is populated, or compared to the current secret for a dry run
+type populator interface {
+	PopulateSecret(ctx context.Context, client *vaultapi.Client, secretValue map[string]any, patch bool) (vault.Metadata, error)
+	DiffSecret(ctx context.Context, client *vaultapi.Client, secretValue map[string]any, patch bool) (vault.SecretValueDiff, vault.Metadata, error)
+}
+
+// populate the secret, or compare it to the current secret without writing if dry run (where the generated keys are not yet generated), and return its version and concourse metadata
+func populateSecret(ctx context.Context, client *vaultapi.Client, secret populator, identifier string, secretValue map[string]any, generatedKeys []string, patch bool, dryRun bool) (string, []concourse.MetadataEntry, error) {
+	if dryRun {
+		// the generate value forms are compared as placeholder values (which are not masked in log output unlike the generate parameters)
+		if len(generatedKeys) > 0 {
+			secretValue = maps.Clone(secretValue)
+			for _, key := range generatedKeys {
+				secretValue[key] = true
+			}
+		}
+
+		// the current version is returned so that concourse does not record a new version
+		diff, secretMetadata, err := secret.DiffSecret(ctx, client, secretValue, patch)
+		if err != nil {
+			return secretMetadata.Version, nil, err
+		}
+		// existing keys would be changed by their generated values
+		for _, key := range generatedKeys {
+			if !slices.Contains(diff.Added, key) && !slices.Contains(diff.Changed, key) {
+				diff.Changed = append(diff.Changed, key)
+			}
+		}
+		slices.Sort(diff.Changed)
+		slog.Info("dry run compared the secret without writing", "secret", identifier, "added_keys", diff.Added, "changed_keys", diff.Changed, "removed_keys", diff.Removed)
+
+		return secretMetadata.Version, DryRunToConcourseMetadata(identifier, diff), nil
+	}
+
+	secretMetadata, err := secret.PopulateSecret(ctx, client, secretValue, patch)
+	if err != nil {
+		return secretMetadata.Version, nil, err
+	}
+
+	// convert rawSecret to concourse metadata
+	return secretMetadata.Version, VaultToConcourseMetadata(identifier, secretMetadata), nil
+}
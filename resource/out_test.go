@@ -0,0 +1,168 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/vault"
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+func TestRunOut(test *testing.T) {
+	// deliver test pipeline file content as stdin the same as actual pipeline execution
+	stdin, err := util.FixtureFile("../cmd/out/fixtures/token_kv.json")
+	if err != nil {
+		test.Fatal(err)
+	}
+	defer stdin.Close()
+	stdout := &bytes.Buffer{}
+
+	if err := RunOut(context.Background(), stdin, stdout, test.TempDir(), clientFactory); err != nil {
+		test.Errorf("out step failed: %s", err)
+	}
+	if !strings.Contains(stdout.String(), `"secret-thefoo":`) || !strings.Contains(stdout.String(), `"secret-thecopy":`) {
+		test.Errorf("out step response was unexpected: %s", stdout.String())
+	}
+
+	// verify populated secret
+	kv2Secret, err := util.VaultClient.KVv2(util.KV2Mount).Get(context.Background(), "thefoo")
+	if err != nil || kv2Secret.Data["newpassword"] != "newsecret" {
+		test.Errorf("kv2 secret was not populated as expected: %v, error: %v", kv2Secret, err)
+	}
+
+	// unchanged secret value is not written again and is flagged
+	stdout.Reset()
+	stdin, _ = util.FixtureFile("../cmd/out/fixtures/token_kv.json")
+	defer stdin.Close()
+	if err := RunOut(context.Background(), stdin, stdout, test.TempDir(), clientFactory); err != nil {
+		test.Errorf("out step with unchanged secrets failed: %s", err)
+	}
+	if unchangedSecret, err := util.VaultClient.KVv2(util.KV2Mount).Get(context.Background(), "thefoo"); err != nil || unchangedSecret.VersionMetadata.Version != kv2Secret.VersionMetadata.Version || !strings.Contains(stdout.String(), `{"name":"secret-thefoo-Unchanged","value":"true"}`) {
+		test.Errorf("unchanged kv2 secret was written as a new version: %v, response: %s, error: %v", unchangedSecret, stdout.String(), err)
+	}
+
+	// dry run reports the key diff without writing
+	stdout.Reset()
+	dryRunParams := `"params":{"secret":{"engine":"kv2","dry_run":true,"secrets":{"dryrun":{"key":"value"}},"copy":{"dryrun-copy":{"engine":"kv2","mount":"secret","path":"thefoo","keys":["newpassword"]}}}}`
+	if err := RunOut(context.Background(), strings.NewReader(`{"source":{"address":"`+util.VaultAddress+`","auth_engine":"token","token":"`+util.VaultToken+`"},`+dryRunParams+`}`), stdout, test.TempDir(), clientFactory); err != nil {
+		test.Errorf("out step dry run failed: %s", err)
+	}
+	for _, expected := range []string{`{"name":"secret-dryrun-DryRun","value":"true"}`, `{"name":"secret-dryrun-AddedKeys","value":"key"}`, `{"name":"secret-dryrun-copy-AddedKeys","value":"newpassword"}`, `"secret-dryrun":""`} {
+		if !strings.Contains(stdout.String(), expected) {
+			test.Errorf("out step dry run response did not contain: %s, actual: %s", expected, stdout.String())
+		}
+	}
+	for _, secretPath := range []string{"dryrun", "dryrun-copy"} {
+		if _, err := util.VaultClient.KVv2(util.KV2Mount).Get(context.Background(), secretPath); err == nil {
+			test.Errorf("out step dry run wrote secret: %s", secretPath)
+		}
+	}
+
+	// dry run reports generated keys as changed or added without generating values (which would fail for the nonexistent policy)
+	stdout.Reset()
+	dryRunGenerateParams := `"params":{"secret":{"engine":"kv2","dry_run":true,"patch":true,"secrets":{"thefoo":{"newpassword":{"generate":{"policy":"nonexistent"}},"generated":{"generate":{"length":8}}}}}}`
+	if err := RunOut(context.Background(), strings.NewReader(`{"source":{"address":"`+util.VaultAddress+`","auth_engine":"token","token":"`+util.VaultToken+`"},`+dryRunGenerateParams+`}`), stdout, test.TempDir(), clientFactory); err != nil {
+		test.Errorf("out step dry run with generated values failed: %s", err)
+	}
+	for _, expected := range []string{`{"name":"secret-thefoo-AddedKeys","value":"generated"}`, `{"name":"secret-thefoo-ChangedKeys","value":"newpassword"}`} {
+		if !strings.Contains(stdout.String(), expected) {
+			test.Errorf("out step dry run with generated values response did not contain: %s, actual: %s", expected, stdout.String())
+		}
+	}
+
+	// dry run of secrets for an engine without comparison support
+	if err := RunOut(context.Background(), strings.NewReader(`{"source":{"address":"`+util.VaultAddress+`","auth_engine":"token","token":"`+util.VaultToken+`"},"params":{"sys":{"engine":"generic","dry_run":true,"secrets":{"policy/foo":{"policy":"bar"}}}}}`), &bytes.Buffer{}, test.TempDir(), clientFactory); !errors.Is(err, vault.ErrInvalidConfig) {
+		test.Errorf("expected invalid config error for generic engine dry run, actual: %v", err)
+	}
+
+	// secret operation failures are joined and returned without a response
+	stdout.Reset()
+	if err := RunOut(context.Background(), strings.NewReader(`{"source":{"address":"`+util.VaultAddress+`","auth_engine":"token","token":"`+util.VaultToken+`"},"params":{"kv":{"engine":"kv1","secrets":{"invalid":{"key":{"from_file":"../invalid"}}}}}}`), stdout, test.TempDir(), clientFactory); err == nil || err.Error() != "non-local file path" {
+		test.Errorf("out step did not return secret operation error: %v", err)
+	}
+	if stdout.Len() > 0 {
+		test.Errorf("out step wrote response despite failure: %s", stdout.String())
+	}
+
+	// invalid request
+	if err := RunOut(context.Background(), strings.NewReader("{"), stdout, test.TempDir(), clientFactory); err == nil {
+		test.Error("out step did not fail on invalid request")
+	}
+
+	// client factory failure
+	stdin, _ = util.FixtureFile("../cmd/out/fixtures/token_kv.json")
+	defer stdin.Close()
+	if err := RunOut(context.Background(), stdin, stdout, test.TempDir(), failingClientFactory); err == nil || err.Error() != "client factory failure" {
+		test.Errorf("out step did not return client factory error: %v", err)
+	}
+}
+
+func TestRunOutRevoke(test *testing.T) {
+	// credentials generation requires a configured role
+	if util.Fake == nil {
+		test.Skip("credentials generation requires the fake vault server")
+	}
+
+	// generate credentials in a get step with metadata file
+	dir := test.TempDir()
+	source := `"source":{"address":"` + util.VaultAddress + `","auth_engine":"token","token":"` + util.VaultToken + `"}`
+	getDir := filepath.Join(dir, "vault-get")
+	os.Mkdir(getDir, 0o700)
+	if err := RunIn(context.Background(), strings.NewReader(`{`+source+`,"params":{"database":{"engine":"database","paths":["`+util.FakeCredentialRole+`","`+util.FakeCredentialRole+`"]}}}`), &bytes.Buffer{}, getDir, clientFactory); err != nil {
+		test.Fatal(err)
+	}
+	leaseIds, err := MetadataFileLeaseIDs(dir, "vault-get/metadata.json", "database")
+	if err != nil || len(leaseIds) != 2 {
+		test.Fatalf("get step did not record lease ids: %v, error: %v", leaseIds, err)
+	}
+
+	// dry run reports the leases and prefixes that would be revoked without revoking them
+	stdout := &bytes.Buffer{}
+	if err := RunOut(context.Background(), strings.NewReader(`{`+source+`,"params":{"database":{"dry_run":true,"revoke_from_file":"vault-get/metadata.json","revoke_prefix":["database/creds"]}}}`), stdout, dir, clientFactory); err != nil {
+		test.Errorf("out step revocation dry run failed: %s", err)
+	}
+	for _, leaseId := range leaseIds {
+		if _, err := util.VaultClient.Sys().Renew(leaseId, 0); err != nil {
+			test.Errorf("dry run revoked lease %s: %s", leaseId, err)
+		}
+		if !strings.Contains(stdout.String(), `{"name":"database-DryRunRevokedLeaseID","value":"`+leaseId+`"}`) {
+			test.Errorf("out step dry run response did not contain lease %s: %s", leaseId, stdout.String())
+		}
+	}
+	if !strings.Contains(stdout.String(), `{"name":"database-DryRunRevokedPrefix","value":"database/creds"}`) {
+		test.Errorf("out step dry run response did not contain prefix: %s", stdout.String())
+	}
+
+	// revoke credentials from get step metadata file
+	stdout.Reset()
+	if err := RunOut(context.Background(), strings.NewReader(`{`+source+`,"params":{"database":{"revoke_from_file":"vault-get/metadata.json"}}}`), stdout, dir, clientFactory); err != nil {
+		test.Errorf("out step revocation failed: %s", err)
+	}
+	for _, leaseId := range leaseIds {
+		if _, err := util.VaultClient.Sys().Renew(leaseId, 0); err == nil {
+			test.Errorf("revoked lease %s was renewable", leaseId)
+		}
+		if !strings.Contains(stdout.String(), `{"name":"database-RevokedLeaseID","value":"`+leaseId+`"}`) {
+			test.Errorf("out step response did not contain revoked lease %s: %s", leaseId, stdout.String())
+		}
+	}
+
+	// revoke by full lease id and prefix
+	stdout.Reset()
+	if err := RunOut(context.Background(), strings.NewReader(`{`+source+`,"params":{"database":{"revoke":["`+leaseIds[0]+`"],"revoke_prefix":["database/creds"]}}}`), stdout, dir, clientFactory); err != nil {
+		test.Errorf("out step revocation by lease id and prefix failed: %s", err)
+	}
+	if !strings.Contains(stdout.String(), `{"name":"database-RevokedPrefix","value":"database/creds"}`) {
+		test.Errorf("out step response did not contain revoked prefix: %s", stdout.String())
+	}
+
+	// nonexistent metadata file
+	if err := RunOut(context.Background(), strings.NewReader(`{`+source+`,"params":{"database":{"revoke_from_file":"nonexistent/metadata.json"}}}`), &bytes.Buffer{}, dir, clientFactory); err == nil {
+		test.Error("out step did not fail on nonexistent metadata file")
+	}
+}
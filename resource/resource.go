@@ -0,0 +1,54 @@
+// Package resource implements the check, in, and out steps of the Concourse Vault resource with injectable input, output, and Vault client construction.
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/logging"
+	"github.com/mschuchard/concourse-vault-resource/redact"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// constructs a vault client from a concourse source; satisfied by vault.NewVaultClient from this module
+type ClientFactory func(ctx context.Context, source concourse.Source) (*vaultapi.Client, error)
+
+// register the sensitive source parameters to mask in log output, and configure the structured logger level and format
+func configureLogging(source concourse.Source) error {
+	redact.Register(source.Token, source.SecretID, source.HMACKey)
+
+	return vault.NewError(vault.ErrInvalidConfig, logging.Configure(source.LogLevel, source.LogFormat))
+}
+
+// read the request, and configure logging from its source before the request is constructed so that request validation logging is masked and respects the source level and format
+func configureRequestLogging(stdin io.Reader) (io.Reader, error) {
+	requestData, err := io.ReadAll(stdin)
+	if err != nil {
+		slog.Error("error reading pipeline input", "error", err)
+		return nil, vault.NewError(vault.ErrInvalidConfig, err)
+	}
+
+	// only the logging and sensitive source parameters are decoded, and leniently without logging (an invalid request is reported by the request constructor)
+	var request struct {
+		Source struct {
+			SecretID  string `json:"secret_id"`
+			Token     string `json:"token"`
+			HMACKey   string `json:"hmac_key"`
+			LogLevel  string `json:"log_level"`
+			LogFormat string `json:"log_format"`
+		} `json:"source"`
+	}
+	json.Unmarshal(requestData, &request)
+	source := request.Source
+	if err = configureLogging(concourse.Source{SecretID: source.SecretID, Token: source.Token, HMACKey: source.HMACKey, LogLevel: source.LogLevel, LogFormat: source.LogFormat}); err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(requestData), nil
+}
@@ -0,0 +1,50 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/logging"
+	"github.com/mschuchard/concourse-vault-resource/vault"
+)
+
+// client factory with default constructor
+var clientFactory ClientFactory = vault.NewVaultClient
+
+// client factory that always fails
+func failingClientFactory(context.Context, concourse.Source) (*vaultapi.Client, error) {
+	return nil, errors.New("client factory failure")
+}
+
+// test request validation logging respects the source log level and format
+func TestConfigureRequestLogging(test *testing.T) {
+	logOutput := &bytes.Buffer{}
+	logging.SetOutput(logOutput)
+	defer func() {
+		logging.Configure("", "")
+		logging.SetOutput(os.Stderr)
+	}()
+
+	// invalid request with unknown source parameter
+	if err := RunCheck(context.Background(), strings.NewReader(`{"source":{"auth_engine":"token","token":"hvs.abcdefghijklmnopqrstuvwx","log_level":"error","log_format":"json","auth_mout":"typo"}}`), &bytes.Buffer{}, clientFactory); !errors.Is(err, vault.ErrInvalidConfig) {
+		test.Errorf("expected invalid config error, actual: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(logOutput.String()), "\n")
+	for _, line := range lines {
+		entry := map[string]any{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil || entry["level"] != "ERROR" {
+			test.Errorf("request validation log entry did not respect the source log level and format: %s", line)
+		}
+	}
+	if !strings.Contains(logOutput.String(), "auth_mout") {
+		test.Errorf("request validation error was not logged: %s", logOutput.String())
+	}
+}
@@ -0,0 +1,69 @@
+package vault
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// AuthMethodConstructor validates the authentication parameters in source, and returns the Vault authentication method
+type AuthMethodConstructor func(source concourse.Source) (vault.AuthMethod, error)
+
+// registry of authentication method constructors with key as enum
+var authMethods = map[enum.AuthEngine]AuthMethodConstructor{}
+
+// register authentication method constructor for enum (typically invoked during package initialization)
+func RegisterAuthMethod(engine enum.AuthEngine, constructor AuthMethodConstructor) {
+	authMethods[engine] = constructor
+}
+
+// return registered authentication method constructor for enum
+func lookupAuthMethod(engine enum.AuthEngine) (AuthMethodConstructor, error) {
+	constructor, ok := authMethods[engine]
+	if !ok {
+		slog.Error("the authentication engine is not currently supported", "auth_engine", engine)
+		return nil, errors.New("invalid Vault authentication engine")
+	}
+
+	return constructor, nil
+}
+
+// warn if source authentication parameters were specified that are ignored by the authentication engine
+func warnIgnoredAuthParams(source concourse.Source, engine enum.AuthEngine, ignoredParams ...string) {
+	// authentication parameters by source json key
+	authParams := map[string]string{
+		"auth_mount": source.AuthMount,
+		"vault_role": source.VaultRole,
+		"secret_id":  source.SecretID,
+		"token":      source.Token,
+	}
+
+	// determine ignored parameters that were specified
+	specifiedParams := []string{}
+	for _, param := range ignoredParams {
+		if len(authParams[param]) > 0 {
+			specifiedParams = append(specifiedParams, param)
+		}
+	}
+
+	if len(specifiedParams) > 0 {
+		slices.Sort(specifiedParams)
+		slog.Warn("ignored parameters were specified for the Vault authentication method", "auth_engine", engine, "ignored_params", strings.Join(specifiedParams, ", "))
+	}
+}
+
+// return default authentication method mount path if mount is unspecified
+func defaultAuthMount(mount string, engine enum.AuthEngine) string {
+	if len(mount) == 0 {
+		slog.Info("using default authentication mount path", "auth_engine", engine, "auth_mount", engine)
+		return string(engine)
+	}
+
+	return mount
+}
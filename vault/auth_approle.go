@@ -0,0 +1,40 @@
+package vault
+
+import (
+	"errors"
+	"log/slog"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/api/auth/approle"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+func init() {
+	RegisterAuthMethod(enum.AppRole, newAppRoleAuth)
+}
+
+// approle authentication constructor
+func newAppRoleAuth(source concourse.Source) (vault.AuthMethod, error) {
+	warnIgnoredAuthParams(source, enum.AppRole, "token")
+
+	// validate role_id and secret_id are provided
+	if len(source.VaultRole) == 0 || len(source.SecretID) == 0 {
+		slog.Error("both vault_role and secret_id must be specified for AppRole authentication")
+		return nil, errors.New("approle credentials absent")
+	}
+
+	// authenticate with approle
+	appRoleAuth, err := approle.NewAppRoleAuth(
+		source.VaultRole,
+		&approle.SecretID{FromString: source.SecretID},
+		approle.WithMountPath(defaultAuthMount(source.AuthMount, enum.AppRole)),
+	)
+	if err != nil {
+		slog.Error("unable to initialize AppRole authentication", "error", err)
+		return nil, err
+	}
+
+	return appRoleAuth, nil
+}
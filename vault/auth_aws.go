@@ -0,0 +1,42 @@
+package vault
+
+import (
+	"log/slog"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/api/auth/aws"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+func init() {
+	RegisterAuthMethod(enum.AWSIAM, newAWSAuth)
+}
+
+// aws iam authentication constructor
+func newAWSAuth(source concourse.Source) (vault.AuthMethod, error) {
+	warnIgnoredAuthParams(source, enum.AWSIAM, "secret_id", "token")
+
+	// determine iam role login option
+	var roleLoginOption aws.LoginOption
+
+	if len(source.VaultRole) > 0 {
+		// use explicitly specified aws role
+		slog.Info("using Vault AWS role for authentication", "vault_role", source.VaultRole)
+		roleLoginOption = aws.WithRole(source.VaultRole)
+	} else {
+		// use default aws iam role (i.e. instance profile)
+		slog.Info("using Vault role in utilized AWS authentication engine with the same name as the currently utilized AWS IAM Role")
+		roleLoginOption = aws.WithIAMAuth()
+	}
+
+	// authenticate with aws iam
+	awsAuth, err := aws.NewAWSAuth(roleLoginOption, aws.WithMountPath(defaultAuthMount(source.AuthMount, enum.AWSIAM)))
+	if err != nil {
+		slog.Error("unable to initialize Vault AWS IAM authentication", "error", err)
+		return nil, err
+	}
+
+	return awsAuth, nil
+}
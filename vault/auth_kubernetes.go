@@ -0,0 +1,39 @@
+package vault
+
+import (
+	"errors"
+	"log/slog"
+
+	vault "github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/api/auth/kubernetes"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+func init() {
+	RegisterAuthMethod(enum.KubernetesSA, newKubernetesAuth)
+}
+
+// kubernetes service account authentication constructor
+func newKubernetesAuth(source concourse.Source) (vault.AuthMethod, error) {
+	warnIgnoredAuthParams(source, enum.KubernetesSA, "secret_id", "token")
+
+	// validate kubernetes vault role input
+	if len(source.VaultRole) == 0 {
+		slog.Error("a Kubernetes Vault role must be specified for the Kubernetes authentication method")
+		return nil, errors.New("no kubernetes vault role specified")
+	}
+
+	// authenticate with kubernetes service account
+	kubeAuth, err := kubernetes.NewKubernetesAuth(
+		source.VaultRole,
+		kubernetes.WithMountPath(defaultAuthMount(source.AuthMount, enum.KubernetesSA)),
+	)
+	if err != nil {
+		slog.Error("unable to initialize Kubernetes service account authentication", "error", err)
+		return nil, err
+	}
+
+	return kubeAuth, nil
+}
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// test authentication method registration and lookup
+func TestRegisterAuthMethod(test *testing.T) {
+	RegisterAuthMethod("test", func(source concourse.Source) (vault.AuthMethod, error) {
+		return &tokenAuth{token: "test"}, nil
+	})
+	defer delete(authMethods, "test")
+
+	constructor, err := lookupAuthMethod("test")
+	if err != nil {
+		test.Error("registered test authentication method lookup failed")
+		test.Error(err)
+	}
+	if authMethod, err := constructor(concourse.Source{}); err != nil || authMethod.(*tokenAuth).token != "test" {
+		test.Errorf("registered test authentication method returned unexpected value: %v, error: %v", authMethod, err)
+	}
+
+	// all enums are registered
+	for _, engine := range []enum.AuthEngine{enum.AppRole, enum.AWSIAM, enum.KubernetesSA, enum.VaultToken} {
+		if _, err := lookupAuthMethod(engine); err != nil {
+			test.Errorf("the authentication engine %s is not registered", engine)
+		}
+	}
+
+	// test errors
+	if _, err = lookupAuthMethod("foo"); err == nil || err.Error() != "invalid Vault authentication engine" {
+		test.Errorf("expected error: invalid Vault authentication engine, actual: %v", err)
+	}
+}
+
+// test authentication method constructors validation
+func TestAuthMethodConstructors(test *testing.T) {
+	tokenMethod, err := newTokenAuth(concourse.Source{Token: "abc.123", AuthMount: "ignored"})
+	if err != nil {
+		test.Error("token authentication method failed to construct")
+		test.Error(err)
+	}
+	if secret, _ := tokenMethod.Login(context.Background(), nil); secret.Auth.ClientToken != "abc.123" {
+		test.Errorf("expected token authentication client token: abc.123, actual: %s", secret.Auth.ClientToken)
+	}
+
+	// test errors
+	if _, err = newTokenAuth(concourse.Source{Token: "foobarbaz123!"}); err == nil || err.Error() != "invalid vault token" {
+		test.Errorf("expected error: invalid vault token, actual: %v", err)
+	}
+	if _, err = newKubernetesAuth(concourse.Source{}); err == nil || err.Error() != "no kubernetes vault role specified" {
+		test.Errorf("expected error: no kubernetes vault role specified, actual: %v", err)
+	}
+	if _, err = newAppRoleAuth(concourse.Source{VaultRole: "myrole"}); err == nil || err.Error() != "approle credentials absent" {
+		test.Errorf("expected error: approle credentials absent, actual: %v", err)
+	}
+}
+
+// test default mount
+func TestDefaultAuthMount(test *testing.T) {
+	if mount := defaultAuthMount("", enum.KubernetesSA); mount != "kubernetes" {
+		test.Errorf("expected default mount: kubernetes, actual: %s", mount)
+	}
+
+	if mount := defaultAuthMount("gcp", enum.AWSIAM); mount != "gcp" {
+		test.Errorf("expected mount input param: gcp, actual: %s", mount)
+	}
+}
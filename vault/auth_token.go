@@ -0,0 +1,40 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// vault token authentication method
+type tokenAuth struct {
+	token string
+}
+
+func init() {
+	RegisterAuthMethod(enum.VaultToken, newTokenAuth)
+}
+
+// token authentication constructor
+func newTokenAuth(source concourse.Source) (vault.AuthMethod, error) {
+	warnIgnoredAuthParams(source, enum.VaultToken, "auth_mount", "vault_role", "secret_id")
+
+	// validate vault token
+	if matched, _ := regexp.MatchString(`^[a-zA-Z0-9.]+$`, source.Token); !matched {
+		slog.Error("the specified Vault Token is invalid")
+		return nil, errors.New("invalid vault token")
+	}
+
+	return &tokenAuth{token: source.Token}, nil
+}
+
+// authenticate with token by returning it as the client token
+func (auth *tokenAuth) Login(ctx context.Context, client *vault.Client) (*vault.Secret, error) {
+	return &vault.Secret{Auth: &vault.SecretAuth{ClientToken: auth.token}}, nil
+}
@@ -3,74 +3,86 @@ package vault
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"net/url"
-	"regexp"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-retryablehttp"
 	vault "github.com/hashicorp/vault/api"
-	"github.com/hashicorp/vault/api/auth/approle"
-	"github.com/hashicorp/vault/api/auth/aws"
-	"github.com/hashicorp/vault/api/auth/kubernetes"
 
 	"github.com/mschuchard/concourse-vault-resource/concourse"
 	"github.com/mschuchard/concourse-vault-resource/enum"
+	"github.com/mschuchard/concourse-vault-resource/redact"
+)
+
+// retry and timeout defaults matching vault api defaults
+const (
+	defaultMaxRetries   = 2
+	defaultMinRetryWait = 1000 * time.Millisecond
+	defaultMaxRetryWait = 1500 * time.Millisecond
+	defaultTimeout      = 60 * time.Second
 )
 
 // configured vault client validated constructor
-func NewVaultClient(source concourse.Source) (*vault.Client, error) {
+func NewVaultClient(ctx context.Context, source concourse.Source) (*vault.Client, error) {
 	// vault address default
 	if len(source.Address) == 0 {
-		source.Address = "http://127.0.0.1:8200"
-	} else {
-		// vault address validation
-		if url, err := url.ParseRequestURI(source.Address); err != nil || len(url.Scheme) == 0 || len(url.Host) == 0 {
-			log.Printf("%s is not a valid Vault server address", source.Address)
+		source.Address = concourse.Addresses{"http://127.0.0.1:8200"}
+	}
+
+	// vault address validation
+	for _, address := range source.Address {
+		if url, err := url.ParseRequestURI(address); err != nil || len(url.Scheme) == 0 || len(url.Host) == 0 {
+			slog.Error("invalid Vault server address", "address", address, "error", err)
 
 			// assign err if it is nil
 			if err == nil {
 				err = errors.New("invalid Vault server address")
 			}
 
-			return nil, err
+			return nil, NewError(ErrInvalidConfig, err)
 		}
 	}
 
-	// insecure validation
-	if !source.Insecure && strings.HasPrefix(source.Address, "http:") {
-		log.Print("insecure input parameter was omitted or specified as false, and address protocol is http")
-		log.Print("insecure will be reset to value of true")
-		source.Insecure = true
-	}
+	// initialize client for first healthy vault server address in order
+	var client, standbyClient *vault.Client
+	var err error
+	for _, address := range source.Address {
+		addressClient, standby, addressErr := healthyClient(ctx, source, address)
+		if addressErr == nil && !standby {
+			client = addressClient
+			break
+		}
+		// retain the first standby in case no other address is active or a performance standby
+		if addressErr == nil {
+			slog.Warn("the Vault server is a standby node that is not a performance standby, and is used only if no other address is active or a performance standby", "address", address)
+			if standbyClient == nil {
+				standbyClient = addressClient
+			}
+			continue
+		}
 
-	// initialize vault api config
-	vaultConfig := &vault.Config{Address: source.Address}
-	if err := vaultConfig.ConfigureTLS(&vault.TLSConfig{Insecure: source.Insecure}); err != nil {
-		log.Print("Vault TLS configuration failed to initialize")
-		return nil, err
+		// join error into collection and attempt next address
+		err = errors.Join(err, addressErr)
+		// step cancellation is not an address failure
+		if ctx.Err() != nil {
+			return nil, err
+		}
 	}
-
-	// initialize vault client
-	client, err := vault.NewClient(vaultConfig)
-	if err != nil {
-		log.Print("Vault client failed to initialize")
-		return nil, err
+	if client == nil && standbyClient != nil {
+		// a standby forwards requests to the active node
+		slog.Warn("no specified Vault server address is active or a performance standby, and requests will be forwarded to the active node by the first standby", "address", standbyClient.Address())
+		client = standbyClient
 	}
-
-	// verify vault is unsealed
-	sealStatus, err := client.Sys().SealStatus()
-	if err != nil {
-		log.Print("unable to verify that the Vault cluster is unsealed")
+	if client == nil {
+		slog.Error("no specified Vault server address is healthy", "error", err)
 		return nil, err
 	}
-	if sealStatus.Sealed {
-		log.Print("the Vault server cluster is sealed and no operations can be executed")
-		return nil, errors.New("vault sealed")
-	}
 
 	// authenticate vault client
-	if err := authClient(source, client); err != nil {
-		log.Print("unable to authenticate Vault client")
+	if err := authClient(ctx, source, client); err != nil {
+		slog.Error("unable to authenticate Vault client", "error", err)
 		return nil, err
 	}
 
@@ -78,139 +90,151 @@ func NewVaultClient(source concourse.Source) (*vault.Client, error) {
 	return client, nil
 }
 
-// determine authentication method and authenticate client
-func authClient(source concourse.Source, client *vault.Client) error {
-	// initialize locals
-	token := source.Token
-	authMount := source.AuthMount
-	vaultRole := source.VaultRole
-	secretID := source.SecretID
-	engine, err := source.AuthEngine.New()
-	if err != nil {
-		return err
+// initialize vault client for address, verify the server is unsealed and can service requests, and return whether it is a standby that is not a performance standby (and therefore forwards requests to the active node)
+func healthyClient(ctx context.Context, source concourse.Source, address string) (*vault.Client, bool, error) {
+	// insecure validation
+	insecure := source.Insecure
+	if !insecure && strings.HasPrefix(address, "http:") {
+		slog.Warn("insecure input parameter was omitted or specified as false, and the address protocol is http, so insecure will be reset to value of true", "address", address)
+		insecure = true
 	}
 
-	// determine vault authentication method
-	switch engine {
-	case enum.VaultToken:
-		// warn if ignored parameters were specified
-		if len(authMount) > 0 || len(vaultRole) > 0 || len(secretID) > 0 {
-			log.Print("ignored parameters were specified for Vault token authentication method")
-			log.Print("auth_mount, vault_role, and secret_id parameters are all ignored for Vault token authentication")
-		}
+	// initialize vault api config
+	vaultConfig := &vault.Config{Address: address}
+	if err := vaultConfig.ConfigureTLS(&vault.TLSConfig{Insecure: insecure}); err != nil {
+		slog.Error("Vault TLS configuration failed to initialize", "address", address, "error", err)
+		return nil, false, NewError(ErrInvalidConfig, err)
+	}
 
-		// validate vault token
-		if matched, _ := regexp.MatchString(`^[a-zA-Z0-9.]+$`, token); !matched {
-			log.Print("the specified Vault Token is invalid")
-			return errors.New("invalid vault token")
-		}
+	// configure retries with backoff and request timeout
+	if err := configureRetries(vaultConfig, source); err != nil {
+		slog.Error("Vault retry and timeout configuration failed to initialize", "error", err)
+		return nil, false, NewError(ErrInvalidConfig, err)
+	}
 
-		// authenticate with token
-		client.SetToken(token)
-	case enum.KubernetesSA:
-		// assign default auth amount if necessary and validate parameters
-		authMount = checkAuthParams(authMount, token, engine)
+	// initialize vault client
+	client, err := vault.NewClient(vaultConfig)
+	if err != nil {
+		slog.Error("Vault client failed to initialize", "address", address, "error", err)
+		return nil, false, NewError(ErrInvalidConfig, err)
+	}
 
-		// validate kubernetes vault role input
-		if len(vaultRole) == 0 {
-			log.Print("a Kubernetes Vault role must be specified for the Kubernetes authentication method")
-			return errors.New("no kubernetes vault role specified")
-		}
+	// verify vault is unsealed
+	sealStatus, err := client.Sys().SealStatusWithContext(ctx)
+	if err != nil {
+		slog.Error("unable to verify that the Vault server is unsealed", "address", address, "error", err)
+		return nil, false, classifyResponse(err)
+	}
+	if sealStatus.Sealed {
+		slog.Error("the Vault server is sealed and no operations can be executed", "address", address)
+		return nil, false, NewError(ErrSealed, errors.New("vault sealed"))
+	}
 
-		// authenticate with kubernetes service account
-		kubeAuth, err := kubernetes.NewKubernetesAuth(
-			vaultRole,
-			kubernetes.WithMountPath(authMount),
-		)
-		if err != nil {
-			log.Print("unable to initialize Kubernetes service account authentication")
-			return err
-		}
+	// verify vault can service requests as an active node or a standby
+	health, err := client.Sys().HealthWithContext(ctx)
+	if err != nil {
+		slog.Error("unable to verify the health of the Vault server", "address", address, "error", err)
+		return nil, false, classifyResponse(err)
+	}
+	if !health.Initialized || health.Sealed {
+		slog.Error("the Vault server is uninitialized or sealed", "address", address)
+		return nil, false, NewError(ErrSealed, errors.New("vault unhealthy"))
+	}
+	if health.ReplicationDRMode == "secondary" {
+		slog.Error("the Vault server is a disaster recovery secondary that cannot service requests", "address", address)
+		return nil, false, NewError(ErrUnavailable, errors.New("vault dr secondary"))
+	}
 
-		err = loginWithMethod(client, kubeAuth, engine)
-	case enum.AWSIAM:
-		// assign default auth amount if necessary and validate parameters
-		authMount = checkAuthParams(authMount, token, engine)
-
-		// determine iam role login option
-		var roleLoginOption aws.LoginOption
-
-		if len(vaultRole) > 0 {
-			// use explicitly specified aws role
-			log.Printf("using Vault AWS role %s for authentication", vaultRole)
-			roleLoginOption = aws.WithRole(vaultRole)
-		} else {
-			// use default aws iam role (i.e. instance profile)
-			log.Print("using Vault role in utilized AWS authentication engine with the same name as the currently utilized AWS IAM Role")
-			roleLoginOption = aws.WithIAMAuth()
-		}
+	return client, health.Standby && !health.PerformanceStandby, nil
+}
 
-		// authenticate with aws iam
-		awsAuth, err := aws.NewAWSAuth(roleLoginOption, aws.WithMountPath(authMount))
-		if err != nil {
-			log.Print("unable to initialize Vault AWS IAM authentication")
-			return err
+// assign retry and timeout source parameters (otherwise defaults) to vault api config
+func configureRetries(vaultConfig *vault.Config, source concourse.Source) error {
+	// retries on connection errors, 412, 429, and 5xx (excluding 501) responses with exponential backoff between the wait bounds (vault api otherwise defaults to linear jitter)
+	vaultConfig.Backoff = retryablehttp.DefaultBackoff
+	vaultConfig.MaxRetries = defaultMaxRetries
+	if source.MaxRetries != nil {
+		if *source.MaxRetries < 0 {
+			slog.Error("the specified max_retries must not be negative", "max_retries", *source.MaxRetries)
+			return errors.New("invalid max retries")
 		}
+		vaultConfig.MaxRetries = *source.MaxRetries
+	}
 
-		// utilize aws authentication with vault client
-		err = loginWithMethod(client, awsAuth, engine)
-	case enum.AppRole:
-		// assign default auth amount if necessary and validate parameters
-		authMount = checkAuthParams(authMount, token, engine)
+	// parse wait bounds and timeout
+	var err error
+	if vaultConfig.MinRetryWait, err = parseDuration(source.MinRetryWait, defaultMinRetryWait, "min_retry_wait"); err != nil {
+		return err
+	}
+	if vaultConfig.MaxRetryWait, err = parseDuration(source.MaxRetryWait, defaultMaxRetryWait, "max_retry_wait"); err != nil {
+		return err
+	}
+	if vaultConfig.MinRetryWait > vaultConfig.MaxRetryWait {
+		slog.Error("min_retry_wait must not be greater than max_retry_wait", "min_retry_wait", vaultConfig.MinRetryWait, "max_retry_wait", vaultConfig.MaxRetryWait)
+		return errors.New("invalid retry wait")
+	}
+	// the timeout is applied as a context deadline to each request including its retries
+	if vaultConfig.Timeout, err = parseDuration(source.Timeout, defaultTimeout, "timeout"); err != nil {
+		return err
+	}
 
-		// validate role_id and secret_id are provided
-		if len(source.VaultRole) == 0 || len(secretID) == 0 {
-			log.Print("both vault_role and secret_id must be specified for AppRole authentication")
-			return errors.New("approle credentials absent")
-		}
+	return nil
+}
 
-		// authenticate with approle
-		appRoleAuth, err := approle.NewAppRoleAuth(
-			source.VaultRole,
-			&approle.SecretID{FromString: secretID},
-			approle.WithMountPath(authMount),
-		)
-		if err != nil {
-			log.Print("unable to initialize AppRole authentication")
-			return err
-		}
+// parse positive duration parameter, or return default if unspecified
+func parseDuration(duration string, defaultDuration time.Duration, param string) (time.Duration, error) {
+	if len(duration) == 0 {
+		return defaultDuration, nil
+	}
 
-		// authenticate with vault approle
-		err = loginWithMethod(client, appRoleAuth, engine)
-	default:
-		log.Printf("%s was input as the authentication engine, but it is not currently supported", engine)
-		return errors.New("invalid Vault authentication engine")
+	parsedDuration, err := time.ParseDuration(duration)
+	if err != nil || parsedDuration <= 0 {
+		slog.Error("the specified duration is not a valid positive duration (e.g. 500ms, 30s, 1m)", param, duration)
+		return 0, errors.New("invalid " + strings.ReplaceAll(param, "_", " "))
 	}
 
-	return err
+	return parsedDuration, nil
 }
 
-// check authentication parameters
-func checkAuthParams(mount string, token string, engine enum.AuthEngine) string {
-	// warn if token specified
-	if len(token) > 0 {
-		log.Print("a token was specified, but will be ignored for Kubernetes service account authentication")
+// determine authentication method and authenticate client
+func authClient(ctx context.Context, source concourse.Source, client *vault.Client) error {
+	// determine registered vault authentication method
+	constructor, err := lookupAuthMethod(source.AuthEngine)
+	if err != nil {
+		return NewError(ErrInvalidConfig, err)
 	}
 
-	// default authentication method mount path
-	if len(mount) == 0 {
-		log.Printf("using default %s authentication mount path at '%s'", engine, engine)
-		mount = string(engine)
+	// validate source parameters and construct authentication method
+	authMethod, err := constructor(source)
+	if err != nil {
+		return NewError(ErrInvalidConfig, err)
 	}
 
-	return mount
+	// authenticate client with authentication method
+	return loginWithMethod(ctx, client, authMethod, source.AuthEngine)
 }
 
 // authenticate vault client with given authentication method
-func loginWithMethod(client *vault.Client, method vault.AuthMethod, engine enum.AuthEngine) error {
+func loginWithMethod(ctx context.Context, client *vault.Client, method vault.AuthMethod, engine enum.AuthEngine) error {
 	// authenticate client with provided method
-	authInfo, err := client.Auth().Login(context.Background(), method)
+	authInfo, err := client.Auth().Login(ctx, method)
 	if err != nil {
-		log.Printf("unable to authenticate to Vault via %s method", engine)
-		return err
+		slog.Error("unable to authenticate to Vault", "auth_engine", engine, "error", err)
+		// step cancellation and outages are not authentication failures
+		if classifiedErr := classifyResponse(err); errors.Is(classifiedErr, ErrUnavailable) || errors.Is(classifiedErr, ErrSealed) || ctx.Err() != nil {
+			return classifiedErr
+		}
+		return NewError(ErrAuthFailure, err)
 	}
 	if authInfo == nil {
-		return errors.New("no auth info was returned after login")
+		return NewError(ErrAuthFailure, errors.New("no auth info was returned after login"))
+	}
+	// mask client and response wrapping tokens in log output
+	if authInfo.Auth != nil {
+		redact.Register(authInfo.Auth.ClientToken)
+	}
+	if authInfo.WrapInfo != nil {
+		redact.Register(authInfo.WrapInfo.Token)
 	}
 
 	return nil
@@ -1,8 +1,14 @@
 package vault
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
 
 	"github.com/mschuchard/concourse-vault-resource/concourse"
 	"github.com/mschuchard/concourse-vault-resource/enum"
@@ -11,60 +17,183 @@ import (
 
 var (
 	basicSourceConfig = concourse.Source{
-		Address:    util.VaultAddress,
+		Address:    concourse.Addresses{util.VaultAddress},
 		AuthEngine: enum.VaultToken,
 		Token:      util.VaultToken,
 	}
 	awsSourceConfig = concourse.Source{
-		Address:    util.VaultAddress,
+		Address:    concourse.Addresses{util.VaultAddress},
 		AuthEngine: enum.AWSIAM,
 		VaultRole:  "myIAMRole",
 	}
 	kubeSourceConfig = concourse.Source{
-		Address:    util.VaultAddress,
+		Address:    concourse.Addresses{util.VaultAddress},
 		AuthEngine: enum.KubernetesSA,
 		VaultRole:  "mySARole",
 	}
 	approleSourceConfig = concourse.Source{
-		Address:    util.VaultAddress,
+		Address:    concourse.Addresses{util.VaultAddress},
 		AuthEngine: enum.AppRole,
 	}
 )
 
 // test client constructor
 func TestNewVaultClient(test *testing.T) {
-	basicClient, err := NewVaultClient(basicSourceConfig)
+	basicClient, err := NewVaultClient(context.Background(), basicSourceConfig)
 	if err != nil {
 		test.Error("authenticating a vault client with a basic token config errored")
 		test.Error(err)
 	}
-	if basicClient.Address() != basicSourceConfig.Address || basicClient.Token() != basicSourceConfig.Token {
+	if basicClient.Address() != basicSourceConfig.Address[0] || basicClient.Token() != basicSourceConfig.Token {
 		test.Error("the authenticated Vault client return failed basic validation")
 		test.Errorf("expected Vault token: %s, actual: %s", basicSourceConfig.Token, basicClient.Token())
 		test.Errorf("expected Vault address: %s, actual: %s", basicSourceConfig.Address, basicClient.Address())
 	}
 
 	// test errors
-	invalidServerConfig := concourse.Source{Address: "https//:foo.com"}
-	if _, err := NewVaultClient(invalidServerConfig); err == nil || err.Error() != "parse \"https//:foo.com\": invalid URI for request" {
+	invalidServerConfig := concourse.Source{Address: concourse.Addresses{"https//:foo.com"}}
+	if _, err := NewVaultClient(context.Background(), invalidServerConfig); !errors.Is(err, ErrInvalidConfig) || err.Error() != "parse \"https//:foo.com\": invalid URI for request" {
 		test.Errorf("expected error: parse \"https//:foo.com\": invalid URI for request, actual: %s", err)
 	}
 }
 
+// test client retries and timeout configuration
+func TestConfigureRetries(test *testing.T) {
+	// defaults
+	vaultConfig := &vault.Config{}
+	if err := configureRetries(vaultConfig, basicSourceConfig); err != nil || vaultConfig.MaxRetries != defaultMaxRetries || vaultConfig.MinRetryWait != defaultMinRetryWait || vaultConfig.MaxRetryWait != defaultMaxRetryWait || vaultConfig.Timeout != defaultTimeout {
+		test.Errorf("unexpected default retry configuration: %v, error: %v", vaultConfig, err)
+	}
+	// backoff doubles from the minimum wait up to the maximum wait
+	if vaultConfig.Backoff == nil {
+		test.Fatal("the retry backoff was not configured")
+	}
+	for attempt, expectedWait := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		if wait := vaultConfig.Backoff(time.Second, 5*time.Second, attempt, nil); wait != expectedWait {
+			test.Errorf("expected exponential backoff wait for attempt %d: %s, actual: %s", attempt, expectedWait, wait)
+		}
+	}
+
+	// specified values including explicitly disabled retries
+	maxRetries := 0
+	retrySource := concourse.Source{MaxRetries: &maxRetries, MinRetryWait: "100ms", MaxRetryWait: "2s", Timeout: "10s"}
+	if err := configureRetries(vaultConfig, retrySource); err != nil || vaultConfig.MaxRetries != 0 || vaultConfig.MinRetryWait != 100*time.Millisecond || vaultConfig.MaxRetryWait != 2*time.Second || vaultConfig.Timeout != 10*time.Second {
+		test.Errorf("unexpected specified retry configuration: %v, error: %v", vaultConfig, err)
+	}
+
+	// test errors
+	maxRetries = -1
+	if err := configureRetries(vaultConfig, concourse.Source{MaxRetries: &maxRetries}); err == nil || err.Error() != "invalid max retries" {
+		test.Errorf("expected error: invalid max retries, actual: %v", err)
+	}
+	if err := configureRetries(vaultConfig, concourse.Source{MinRetryWait: "2s", MaxRetryWait: "1s"}); err == nil || err.Error() != "invalid retry wait" {
+		test.Errorf("expected error: invalid retry wait, actual: %v", err)
+	}
+	if err := configureRetries(vaultConfig, concourse.Source{MinRetryWait: "soon"}); err == nil || err.Error() != "invalid min retry wait" {
+		test.Errorf("expected error: invalid min retry wait, actual: %v", err)
+	}
+	if err := configureRetries(vaultConfig, concourse.Source{Timeout: "-1s"}); err == nil || err.Error() != "invalid timeout" {
+		test.Errorf("expected error: invalid timeout, actual: %v", err)
+	}
+}
+
+// test client retries transient failures
+func TestNewVaultClientRetries(test *testing.T) {
+	fake := util.NewFakeVault()
+	defer fake.Close()
+
+	// transient failures within max retries are retried
+	maxRetries := 2
+	retrySource := concourse.Source{Address: concourse.Addresses{fake.URL}, AuthEngine: enum.VaultToken, Token: util.VaultToken, MaxRetries: &maxRetries, MinRetryWait: "1ms", MaxRetryWait: "5ms"}
+	fake.FailRequests(2, http.StatusServiceUnavailable)
+	client, err := NewVaultClient(context.Background(), retrySource)
+	if err != nil {
+		test.Errorf("client did not retry transient failures: %v", err)
+	}
+	fake.FailRequests(1, http.StatusTooManyRequests)
+	if _, _, err := (kv2Engine{}).Read(context.Background(), client, util.KV2Mount, util.KVPath, ""); err != nil {
+		test.Errorf("kv2 read did not retry transient failures: %v", err)
+	}
+
+	// transient failures beyond max retries fail
+	fake.FailRequests(3, http.StatusServiceUnavailable)
+	if _, err := NewVaultClient(context.Background(), retrySource); err == nil {
+		test.Error("client did not fail after exhausting retries")
+	}
+
+	// step cancellation interrupts client operations
+	fake.FailRequests(0, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewVaultClient(ctx, retrySource); !errors.Is(err, context.Canceled) {
+		test.Errorf("expected error: context canceled, actual: %v", err)
+	}
+}
+
+// test client failover across multiple addresses
+func TestNewVaultClientFailover(test *testing.T) {
+	sealedFake, standbyFake, perfStandbyFake := util.NewFakeVault(), util.NewFakeVault(), util.NewFakeVault()
+	defer sealedFake.Close()
+	defer standbyFake.Close()
+	defer perfStandbyFake.Close()
+	sealedFake.SetHealth(true, false, false)
+	standbyFake.SetHealth(false, true, false)
+	perfStandbyFake.SetHealth(false, true, true)
+
+	// first healthy address in order is utilized
+	failoverSource := basicSourceConfig
+	failoverSource.Address = concourse.Addresses{sealedFake.URL, standbyFake.URL, perfStandbyFake.URL, util.VaultAddress}
+	client, err := NewVaultClient(context.Background(), failoverSource)
+	if err != nil || client.Address() != perfStandbyFake.URL {
+		test.Errorf("client did not fail over to performance standby: %v, error: %v", client, err)
+	}
+
+	// standby that is not a performance standby is utilized only if no other address is active or a performance standby (e.g. single address behind a load balancer)
+	for _, addresses := range []concourse.Addresses{{standbyFake.URL}, {sealedFake.URL, standbyFake.URL}, {standbyFake.URL, sealedFake.URL}} {
+		failoverSource.Address = addresses
+		if client, err = NewVaultClient(context.Background(), failoverSource); err != nil || client.Address() != standbyFake.URL {
+			test.Errorf("client did not fall back to standby for addresses %v: %v, error: %v", addresses, client, err)
+		}
+	}
+	failoverSource.Address = concourse.Addresses{standbyFake.URL, util.VaultAddress}
+	if client, err = NewVaultClient(context.Background(), failoverSource); err != nil || client.Address() != util.VaultAddress {
+		test.Errorf("client did not prefer active address to standby: %v, error: %v", client, err)
+	}
+
+	// all addresses unhealthy
+	otherSealedFake := util.NewFakeVault()
+	defer otherSealedFake.Close()
+	otherSealedFake.SetHealth(true, false, false)
+	failoverSource.Address = concourse.Addresses{sealedFake.URL, otherSealedFake.URL}
+	if _, err = NewVaultClient(context.Background(), failoverSource); err == nil || err.Error() != "vault sealed\nvault sealed" {
+		test.Errorf("expected error: vault sealed\nvault sealed, actual: %v", err)
+	}
+
+	// unreachable address
+	maxRetries := 0
+	failoverSource.MaxRetries = &maxRetries
+	unreachableFake := util.NewFakeVault()
+	unreachableFake.Close()
+	failoverSource.Address = concourse.Addresses{unreachableFake.URL, util.VaultAddress}
+	if client, err = NewVaultClient(context.Background(), failoverSource); err != nil || client.Address() != util.VaultAddress {
+		test.Errorf("client did not fail over from unreachable address: %v, error: %v", client, err)
+	}
+}
+
 // test client auth
 func TestAuthClient(test *testing.T) {
-	if err := authClient(awsSourceConfig, util.VaultClient); err == nil || !strings.Contains(err.Error(), "NoCredentialProviders: no valid providers in chain") {
+	if err := authClient(context.Background(), awsSourceConfig, util.VaultClient); err == nil || !strings.Contains(err.Error(), "NoCredentialProviders: no valid providers in chain") {
 		test.Error("authenticating a vault client with aws did not error in the expected manner")
 		test.Errorf("expected error (contains): NoCredentialProviders: no valid providers in chain, actual: %v", err)
 	}
 
 	awsSourceConfig.VaultRole = ""
-	if err := authClient(awsSourceConfig, util.VaultClient); err == nil || !strings.Contains(err.Error(), "NoCredentialProviders: no valid providers in chain") {
+	if err := authClient(context.Background(), awsSourceConfig, util.VaultClient); err == nil || !strings.Contains(err.Error(), "NoCredentialProviders: no valid providers in chain") {
 		test.Error("authenticating a vault client with aws did not error in the expected manner")
 		test.Errorf("expected error (contains): NoCredentialProviders: no valid providers in chain, actual: %v", err)
 	}
 
-	if err := authClient(kubeSourceConfig, util.VaultClient); err == nil || !strings.Contains(err.Error(), "error reading service account token from default location") {
+	if err := authClient(context.Background(), kubeSourceConfig, util.VaultClient); err == nil || !strings.Contains(err.Error(), "error reading service account token from default location") {
 		test.Error("authenticating a vault client with kubernetes did not error in the expected manner")
 		test.Errorf("expected error (contains): error reading service account token from default location, actual: %v", err)
 	}
@@ -83,47 +212,41 @@ func TestAuthClient(test *testing.T) {
 	approleSourceConfig.VaultRole = roleID.Data["role_id"].(string)
 	approleSourceConfig.SecretID = secretID.Data["secret_id"].(string)
 
-	if err := authClient(approleSourceConfig, util.VaultClient); err != nil {
+	if err := authClient(context.Background(), approleSourceConfig, util.VaultClient); err != nil {
 		test.Error("authenticating a vault client with approle config errored")
 		test.Error(err)
 	}
 
 	// this needs to be last to ensure the client is authenticated with a root token for all other tests
-	if err := authClient(basicSourceConfig, util.VaultClient); err != nil {
+	if err := authClient(context.Background(), basicSourceConfig, util.VaultClient); err != nil {
 		test.Error("authenticating a vault client with a basic token config errored")
 		test.Error(err)
 	}
 
 	// test errors
 	invalidAuth := concourse.Source{AuthEngine: "does not exist"}
-	if err := authClient(invalidAuth, util.VaultClient); err == nil || err.Error() != "invalid authengine enum" {
-		test.Errorf("expected error: invalid authengine enum, actual: %s", err)
+	if err := authClient(context.Background(), invalidAuth, util.VaultClient); !errors.Is(err, ErrInvalidConfig) || err.Error() != "invalid Vault authentication engine" {
+		test.Errorf("expected error: invalid Vault authentication engine, actual: %s", err)
 	}
 
 	invalidToken := concourse.Source{AuthEngine: enum.VaultToken, Token: "foobarbaz123!"}
-	if err := authClient(invalidToken, util.VaultClient); err == nil || err.Error() != "invalid vault token" {
+	if err := authClient(context.Background(), invalidToken, util.VaultClient); err == nil || err.Error() != "invalid vault token" {
 		test.Errorf("expected error: invalid vault token, actual: %s", err)
 	}
 
 	kubeSourceConfig.VaultRole = ""
-	if err := authClient(kubeSourceConfig, util.VaultClient); err == nil || err.Error() != "no kubernetes vault role specified" {
+	if err := authClient(context.Background(), kubeSourceConfig, util.VaultClient); err == nil || err.Error() != "no kubernetes vault role specified" {
 		test.Errorf("expected error: no kubernetes vault role specified, actual: %s", err)
 	}
 
-	approleSourceConfig.VaultRole = ""
-	if err := authClient(approleSourceConfig, util.VaultClient); err == nil || err.Error() != "approle credentials absent" {
-		test.Errorf("expected error: approle credentials absent, actual: %s", err)
-	}
-}
-
-// test default mount
-func TestCheckAuthParams(test *testing.T) {
-	if mount := checkAuthParams("", "", enum.KubernetesSA); mount != "kubernetes" {
-		test.Errorf("expected default mount: kubernetes, actual: %s", mount)
+	approleSourceConfig.SecretID = "invalid"
+	if err := authClient(context.Background(), approleSourceConfig, util.VaultClient); !errors.Is(err, ErrAuthFailure) {
+		test.Errorf("expected authentication failure error, actual: %v", err)
 	}
 
-	if mount := checkAuthParams("gcp", "", enum.AWSIAM); mount != "gcp" {
-		test.Errorf("expected mount input param: gcp, actual: %s", mount)
+	approleSourceConfig.VaultRole = ""
+	if err := authClient(context.Background(), approleSourceConfig, util.VaultClient); err == nil || err.Error() != "approle credentials absent" {
+		test.Errorf("expected error: approle credentials absent, actual: %s", err)
 	}
 }
 
@@ -0,0 +1,143 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/redact"
+)
+
+// names of the keys (never the values) that differ between the current and desired secret values
+type SecretValueDiff struct {
+	Added   []string
+	Changed []string
+	Removed []string
+}
+
+// the current and desired secret values are identical
+func (diff SecretValueDiff) IsEmpty() bool {
+	return len(diff.Added) == 0 && len(diff.Changed) == 0 && len(diff.Removed) == 0
+}
+
+// compare the current and desired secret values by key in sorted order
+func DiffSecretValue(current map[string]any, desired map[string]any) (SecretValueDiff, error) {
+	// normalize values through json so that values compare equal regardless of their decoding (e.g. json.Number and float64)
+	current, err := normalizeSecretValue(current)
+	if err != nil {
+		return SecretValueDiff{}, err
+	}
+	desired, err = normalizeSecretValue(desired)
+	if err != nil {
+		return SecretValueDiff{}, err
+	}
+
+	diff := SecretValueDiff{Added: []string{}, Changed: []string{}, Removed: []string{}}
+	for _, key := range slices.Sorted(maps.Keys(desired)) {
+		currentValue, ok := current[key]
+		if !ok {
+			diff.Added = append(diff.Added, key)
+		} else if !reflect.DeepEqual(currentValue, desired[key]) {
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(current)) {
+		if _, ok := desired[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	return diff, nil
+}
+
+// secret engine whose latest secret value can be read without logging a nonexistent secret as an error
+type latestReader interface {
+	readLatest(ctx context.Context, client *vault.Client, mount string, path string) (map[string]any, Metadata, error)
+}
+
+// compare the secret value to the current secret value of the engine (empty if the secret does not exist), and return the key diff and current metadata (empty if the secret does not exist)
+func diffCurrentSecretValue(ctx context.Context, client *vault.Client, reader latestReader, mount string, path string, secretValue map[string]any, patch bool) (SecretValueDiff, Metadata, error) {
+	currentValue, metadata, err := reader.readLatest(ctx, client, mount, path)
+	// mask current secret values in log output
+	redact.RegisterSecretValue(currentValue)
+	if err != nil {
+		// a nonexistent secret or deleted latest version is expected before the first write, and is empty
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrVersionMissing) {
+			return SecretValueDiff{}, Metadata{}, err
+		}
+		slog.Debug("the secret does not exist, and is compared as empty", "mount", mount, "path", path)
+		currentValue, metadata = map[string]any{}, Metadata{}
+	}
+
+	// patch merges into the current secret value
+	desiredValue := secretValue
+	if patch {
+		desiredValue = patchSecretValue(currentValue, secretValue)
+	}
+
+	diff, err := DiffSecretValue(currentValue, desiredValue)
+	return diff, metadata, err
+}
+
+// return the current metadata flagged as unchanged if the secret value is identical to the current secret value, so that the write can be skipped
+func unchangedSecretValue(ctx context.Context, client *vault.Client, differ Differ, mount string, path string, secretValue map[string]any, patch bool) (Metadata, bool) {
+	diff, metadata, err := differ.Diff(ctx, client, mount, path, secretValue, patch)
+	// the secret is written if the current secret cannot be read (e.g. policy with only write capabilities)
+	if err != nil {
+		slog.Debug("the current secret could not be compared, and the secret will be written", "mount", mount, "path", path, "error", err)
+		return Metadata{}, false
+	}
+	// empty metadata signifies a nonexistent secret
+	if !diff.IsEmpty() || metadata == (Metadata{}) {
+		return Metadata{}, false
+	}
+	slog.Info("the secret value is unchanged, and the secret will not be written", "mount", mount, "path", path, "version", metadata.Version)
+
+	return Metadata{Version: metadata.Version, Unchanged: true}, true
+}
+
+// return the secret value after a recursive json merge patch (RFC 7386 as with kv2 patch) where null values remove keys
+func patchSecretValue(current map[string]any, patch map[string]any) map[string]any {
+	patched := maps.Clone(current)
+	if patched == nil {
+		patched = map[string]any{}
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(patched, key)
+			continue
+		}
+		// nested objects are merged into the current nested object (replacing a current non-object value)
+		if patchObject, ok := value.(map[string]any); ok {
+			currentObject, _ := patched[key].(map[string]any)
+			patched[key] = patchSecretValue(currentObject, patchObject)
+		} else {
+			patched[key] = value
+		}
+	}
+
+	return patched
+}
+
+// round trip the secret value through json
+func normalizeSecretValue(secretValue map[string]any) (map[string]any, error) {
+	secretData, err := json.Marshal(secretValue)
+	if err != nil {
+		slog.Error("unable to marshal secret value for comparison", "error", err)
+		return nil, err
+	}
+
+	normalizedValue := map[string]any{}
+	if err = json.Unmarshal(secretData, &normalizedValue); err != nil {
+		slog.Error("unable to unmarshal secret value for comparison", "error", err)
+		return nil, err
+	}
+
+	return normalizedValue, nil
+}
@@ -0,0 +1,83 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/logging"
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+// test secret value key diff
+func TestDiffSecretValue(test *testing.T) {
+	current := map[string]any{"number": json.Number("1"), "nested": map[string]any{"key": "value"}, "changed": "old", "removed": "value"}
+	desired := map[string]any{"number": float64(1), "nested": map[string]any{"key": "value"}, "changed": "new", "added": "value", "another": true}
+
+	diff, err := DiffSecretValue(current, desired)
+	if err != nil {
+		test.Error(err)
+	}
+	expectedDiff := SecretValueDiff{Added: []string{"added", "another"}, Changed: []string{"changed"}, Removed: []string{"removed"}}
+	if !reflect.DeepEqual(diff, expectedDiff) || diff.IsEmpty() {
+		test.Errorf("expected diff: %v, actual: %v", expectedDiff, diff)
+	}
+
+	// identical values
+	if diff, err = DiffSecretValue(current, current); err != nil || !diff.IsEmpty() {
+		test.Errorf("expected empty diff for identical values, actual: %v, error: %v", diff, err)
+	}
+
+	// unmarshallable value
+	if _, err = DiffSecretValue(current, map[string]any{"invalid": make(chan int)}); err == nil {
+		test.Error("expected error for unmarshallable secret value")
+	}
+}
+
+// test json merge patch of secret value
+func TestPatchSecretValue(test *testing.T) {
+	patched := patchSecretValue(map[string]any{"kept": "value", "changed": "old", "removed": "value"}, map[string]any{"changed": "new", "removed": nil, "added": "value"})
+	if expected := map[string]any{"kept": "value", "changed": "new", "added": "value"}; !reflect.DeepEqual(patched, expected) {
+		test.Errorf("expected patched value: %v, actual: %v", expected, patched)
+	}
+	// nested objects are merged recursively
+	current := map[string]any{"db": map[string]any{"user": "admin", "password": "old", "options": map[string]any{"tls": true}}, "replaced": "value"}
+	patch := map[string]any{"db": map[string]any{"password": "new", "options": map[string]any{"tls": nil}}, "replaced": map[string]any{"key": "value", "removed": nil}}
+	expected := map[string]any{"db": map[string]any{"user": "admin", "password": "new", "options": map[string]any{}}, "replaced": map[string]any{"key": "value"}}
+	if patched = patchSecretValue(current, patch); !reflect.DeepEqual(patched, expected) {
+		test.Errorf("expected recursively patched value: %v, actual: %v", expected, patched)
+	}
+	if current["db"].(map[string]any)["password"] != "old" {
+		test.Errorf("the current value was modified by the patch: %v", current)
+	}
+	if patched = patchSecretValue(nil, map[string]any{"added": "value"}); patched["added"] != "value" {
+		test.Errorf("expected patch of nil value, actual: %v", patched)
+	}
+}
+
+// test diff of nonexistent secrets is quiet
+func TestDiffCurrentSecretValueNonexistent(test *testing.T) {
+	logOutput := &bytes.Buffer{}
+	logging.SetOutput(logOutput)
+	defer logging.SetOutput(os.Stderr)
+
+	for engine, mount := range map[latestReader]string{kv1Engine{}: util.KV1Mount, kv2Engine{}: util.KV2Mount} {
+		diff, metadata, err := diffCurrentSecretValue(context.Background(), util.VaultClient, engine, mount, "does/not/exist", map[string]any{"key": "value"}, false)
+		if err != nil || !reflect.DeepEqual(diff.Added, []string{"key"}) || metadata != (Metadata{}) {
+			test.Errorf("unexpected diff for nonexistent secret in %s mount: %v, metadata: %v, error: %v", mount, diff, metadata, err)
+		}
+	}
+	if strings.Contains(logOutput.String(), "level=ERROR") {
+		test.Errorf("diff of nonexistent secret logged an error: %s", logOutput.String())
+	}
+
+	// reading a nonexistent secret is still an error
+	if _, _, err := (kv2Engine{}).Read(context.Background(), util.VaultClient, util.KV2Mount, "does/not/exist", ""); !errors.Is(err, ErrNotFound) || !strings.Contains(logOutput.String(), "level=ERROR") {
+		test.Errorf("read of nonexistent secret did not log an error: %v", err)
+	}
+}
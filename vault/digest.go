@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/redact"
+)
+
+// keyed digest of secret value as version for unversioned secrets so that changes are detected without exposing a plaintext hash
+func DigestVersion(secretValue map[string]any, key []byte) (string, error) {
+	// json marshalling sorts map keys so the serialization is stable
+	secretData, err := json.Marshal(secretValue)
+	if err != nil {
+		slog.Error("unable to marshal secret value for digest", "error", err)
+		return "", err
+	}
+
+	digest := hmac.New(sha256.New, key)
+	digest.Write(secretData)
+
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// digest of the per secret versions as a single composite version that changes whenever any secret version changes
+func CompositeVersion(secretVersions map[string]string) (string, error) {
+	// json marshalling sorts map keys so the serialization is stable
+	versionsData, err := json.Marshal(secretVersions)
+	if err != nil {
+		slog.Error("unable to marshal secret versions for composite version", "error", err)
+		return "", err
+	}
+
+	digest := sha256.Sum256(versionsData)
+
+	return hex.EncodeToString(digest[:]), nil
+}
+
+// return digest version of unversioned secret value, or dummy version if no digest key
+func digestCheck(ctx context.Context, client *vault.Client, engine SecretEngine, mount string, path string, key []byte) ([]SecretVersion, error) {
+	if len(key) == 0 {
+		slog.Warn("the secret is unversioned, and an hmac_key must be specified in source to detect changes", "mount", mount, "path", path)
+		return []SecretVersion{{Version: "0"}}, nil
+	}
+
+	secretValue, _, err := engine.Read(ctx, client, mount, path, "")
+	redact.RegisterSecretValue(secretValue)
+	if err != nil {
+		slog.Error("the secret could not be read for its digest version", "mount", mount, "path", path, "error", err)
+		return nil, err
+	}
+
+	version, err := DigestVersion(secretValue, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return []SecretVersion{{Version: version}}, nil
+}
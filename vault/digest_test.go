@@ -0,0 +1,47 @@
+package vault
+
+import "testing"
+
+// test digest version of secret value
+func TestDigestVersion(test *testing.T) {
+	secretValue := map[string]any{"password": "supersecret", "username": "admin", "nested": map[string]any{"b": 2, "a": 1}}
+
+	version, err := DigestVersion(secretValue, []byte("hmackey"))
+	if err != nil || len(version) != 64 {
+		test.Errorf("unexpected digest version: %s, error: %v", version, err)
+	}
+
+	// stable for equal secret values
+	if stableVersion, _ := DigestVersion(map[string]any{"username": "admin", "nested": map[string]any{"a": 1, "b": 2}, "password": "supersecret"}, []byte("hmackey")); stableVersion != version {
+		test.Errorf("digest version was not stable for equal secret values: %s, %s", version, stableVersion)
+	}
+
+	// changes with secret value and key
+	if changedVersion, _ := DigestVersion(map[string]any{"password": "changed", "username": "admin", "nested": map[string]any{"b": 2, "a": 1}}, []byte("hmackey")); changedVersion == version {
+		test.Error("digest version did not change with secret value")
+	}
+	if keyedVersion, _ := DigestVersion(secretValue, []byte("otherkey")); keyedVersion == version {
+		test.Error("digest version did not change with key")
+	}
+
+	// test errors
+	if _, err = DigestVersion(map[string]any{"invalid": func() {}}, []byte("hmackey")); err == nil {
+		test.Error("expected error for unmarshallable secret value")
+	}
+}
+
+// test composite version of secret versions
+func TestCompositeVersion(test *testing.T) {
+	version, err := CompositeVersion(map[string]string{"secret-foo/bar": "1", "kv-foo/bar": "abcdef"})
+	if err != nil || len(version) != 64 {
+		test.Errorf("unexpected composite version: %s, error: %v", version, err)
+	}
+
+	// changes with any secret version
+	if changedVersion, _ := CompositeVersion(map[string]string{"secret-foo/bar": "2", "kv-foo/bar": "abcdef"}); changedVersion == version {
+		test.Error("composite version did not change with secret version")
+	}
+	if addedVersion, _ := CompositeVersion(map[string]string{"secret-foo/bar": "1", "kv-foo/bar": "abcdef", "secret-bar/baz": "1"}); addedVersion == version {
+		test.Error("composite version did not change with additional secret")
+	}
+}
@@ -0,0 +1,165 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// SecretEngine defines the operations for a Vault secrets engine
+type SecretEngine interface {
+	// default mount path when the mount is unspecified
+	DefaultMount() string
+	// return secret value and metadata for the version (empty signifies latest) (GET/READ/READ)
+	Read(ctx context.Context, client *vault.Client, mount string, path string, version string) (map[string]any, Metadata, error)
+	// write secret value and return metadata (POST/WRITE/CREATE+PUT/PATCH/UPDATE)
+	Write(ctx context.Context, client *vault.Client, mount string, path string, secretValue map[string]any, patch bool) (Metadata, error)
+	// renew secret lease and return updated metadata
+	Renew(ctx context.Context, client *vault.Client, mount string, path string, lease Lease) (Metadata, error)
+	// return current version of secret
+	Version(ctx context.Context, client *vault.Client, mount string, path string) (string, error)
+	// return versions of secret from input version through current version
+	Check(ctx context.Context, client *vault.Client, mount string, path string, version string, options CheckOptions) ([]SecretVersion, error)
+}
+
+// Lister is a secret engine whose secrets can be listed recursively beneath a prefix
+type Lister interface {
+	// logical path for listing the secrets directly beneath the prefix
+	ListPath(mount string, prefix string) string
+}
+
+// Differ is a secret engine whose current secret value can be read without side effects and compared to a secret value
+type Differ interface {
+	// compare secret value to the current secret value (empty if the secret does not exist), and return the key diff and current metadata
+	Diff(ctx context.Context, client *vault.Client, mount string, path string, secretValue map[string]any, patch bool) (SecretValueDiff, Metadata, error)
+}
+
+// Versioner is a secret engine whose secret versions can be checked without side effects (for the generic engine only if reading the path has none)
+type Versioner interface {
+	// secrets are unversioned, and are versioned by the keyed digest of the secret value
+	DigestVersioned() bool
+}
+
+// secret version returned by check
+type SecretVersion struct {
+	Version string
+	// creation time of the version if tracked by the secrets engine
+	CreatedTime time.Time
+}
+
+// dynamic secret lease for renewal
+type Lease struct {
+	// full lease id, or lease id suffix following the secret lease path
+	ID string
+	// requested lease extension (zero signifies the lease default)
+	Increment time.Duration
+}
+
+// options for secret versions check
+type CheckOptions struct {
+	// dynamic secret lease to renew
+	Lease Lease
+	// key for digest versions of unversioned secrets (empty signifies dummy version)
+	DigestKey []byte
+}
+
+// registry of secret engines with key as enum
+var secretEngines = map[enum.SecretEngine]SecretEngine{}
+
+// register secret engine implementation for enum (typically invoked during package initialization)
+func RegisterSecretEngine(engine enum.SecretEngine, secretEngine SecretEngine) {
+	secretEngines[engine] = secretEngine
+}
+
+// validator of the secret engines in requests registered for request constructors
+type engineValidator struct{}
+
+func init() {
+	concourse.RegisterEngineValidator(engineValidator{})
+}
+
+func (engineValidator) ValidateCompositeSecrets(source concourse.Source) error {
+	return ValidateCompositeSecrets(source)
+}
+
+func (engineValidator) ValidateDiff(engine enum.SecretEngine) error {
+	return ValidateDiff(engine)
+}
+
+// return registered secret engine implementation for enum
+func lookupSecretEngine(engine enum.SecretEngine) (SecretEngine, error) {
+	secretEngine, ok := secretEngines[engine]
+	if !ok {
+		slog.Error("an invalid secret engine was selected", "engine", engine)
+		return nil, NewError(ErrInvalidConfig, errors.New("invalid secret engine"))
+	}
+
+	return secretEngine, nil
+}
+
+// return whether the registered secret engine versions its secrets by the keyed digest of the secret value
+func DigestVersioned(engine enum.SecretEngine) bool {
+	versioner, ok := secretEngines[engine].(Versioner)
+	return ok && versioner.DigestVersioned()
+}
+
+// validate the secret engines of the composite secrets in source support checks and prefixes
+func ValidateCompositeSecrets(source concourse.Source) error {
+	for _, compositeSecret := range source.Secrets {
+		secretEngine, err := lookupSecretEngine(compositeSecret.Engine)
+		if err != nil {
+			return err
+		}
+
+		versioner, ok := secretEngine.(Versioner)
+		if !ok {
+			slog.Error("the composite secret engine must support checks without side effects (e.g. kv1, kv2, or generic)", "engine", compositeSecret.Engine)
+			return NewError(ErrInvalidConfig, errors.New("invalid composite secret engine"))
+		}
+		// unversioned secrets are versioned by digest
+		if versioner.DigestVersioned() && len(source.HMACKey) == 0 {
+			slog.Error("the composite secret is unversioned, and an hmac_key must be specified in source", "engine", compositeSecret.Engine, "mount", compositeSecret.Mount, "path", compositeSecret.Path)
+			return NewError(ErrInvalidConfig, errors.New("hmac key required for unversioned composite secret"))
+		}
+		if _, ok = secretEngine.(Lister); compositeSecret.Prefix && !ok {
+			slog.Error("prefixes are only supported with secret engines that support listing (e.g. kv1 and kv2)", "engine", compositeSecret.Engine, "mount", compositeSecret.Mount, "path", compositeSecret.Path)
+			return NewError(ErrInvalidConfig, errors.New("prefix specified with unlistable engine"))
+		}
+	}
+
+	return nil
+}
+
+// validate the secret engine supports comparison of secret values for dry runs
+func ValidateDiff(engine enum.SecretEngine) error {
+	secretEngine, err := lookupSecretEngine(engine)
+	if err != nil {
+		return err
+	}
+	// reading secrets from other engines may have side effects such as generating credentials
+	if _, ok := secretEngine.(Differ); !ok {
+		slog.Error("secrets can only be compared for secret engines that support reading without side effects (e.g. kv1 and kv2)", "engine", engine)
+		return NewError(ErrInvalidConfig, errors.New("diff unsupported for secret engine"))
+	}
+
+	return nil
+}
+
+// static secret engines are not renewable
+type staticEngine struct{}
+
+func (staticEngine) Renew(ctx context.Context, client *vault.Client, mount string, path string, lease Lease) (Metadata, error) {
+	slog.Error("the input secret is static and not renewable", "mount", mount, "path", path)
+	return Metadata{}, NewError(ErrInvalidConfig, errors.New("non-renewable secret"))
+}
+
+// calculate the expiration time for version of dynamic secret
+func expirationVersion(leaseDuration time.Duration) string {
+	return time.Now().Local().Add(leaseDuration).Format("2006-01-02-150405")
+}
@@ -0,0 +1,139 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// dynamic credential generator secret engine
+type credentialEngine struct {
+	engine enum.SecretEngine
+	// credential endpoint in addition to the default creds endpoint (e.g. sts for aws)
+	endpoint string
+}
+
+// ssh dynamic credential generator secret engine
+type sshEngine struct {
+	credentialEngine
+}
+
+func init() {
+	for _, engine := range []enum.SecretEngine{enum.Azure, enum.Consul, enum.Kubernetes, enum.Nomad, enum.RabbitMQ, enum.Terraform} {
+		RegisterSecretEngine(engine, credentialEngine{engine: engine})
+	}
+	RegisterSecretEngine(enum.AWS, credentialEngine{engine: enum.AWS, endpoint: "sts"})
+	RegisterSecretEngine(enum.Database, credentialEngine{engine: enum.Database, endpoint: "static-creds"})
+	RegisterSecretEngine(enum.SSH, sshEngine{credentialEngine{engine: enum.SSH}})
+}
+
+func (engine credentialEngine) DefaultMount() string {
+	return string(engine.engine)
+}
+
+// determine credential path from secret path which either begins with a credential endpoint, or otherwise is a role for the default creds endpoint
+func (engine credentialEngine) credentialPath(mount string, path string) string {
+	endpoint, _, _ := strings.Cut(path, "/")
+	if endpoint == "creds" || (len(engine.endpoint) > 0 && endpoint == engine.endpoint) {
+		return mount + "/" + path
+	}
+
+	return mount + "/creds/" + path
+}
+
+// generate credentials (version is ignored)
+func (engine credentialEngine) Read(ctx context.Context, client *vault.Client, mount string, path string, version string) (map[string]any, Metadata, error) {
+	rawSecret, err := client.Logical().ReadWithContext(ctx, engine.credentialPath(mount, path))
+
+	return engine.credentials(rawSecret, err, path)
+}
+
+// generate ssh credentials (version is ignored)
+func (engine sshEngine) Read(ctx context.Context, client *vault.Client, mount string, path string, version string) (map[string]any, Metadata, error) {
+	rawSecret, err := client.SSHWithMountPoint(mount).CredentialWithContext(ctx, path, map[string]any{})
+
+	return engine.credentials(rawSecret, err, path)
+}
+
+// convert generated raw secret to credentials and metadata
+func (engine credentialEngine) credentials(rawSecret *vault.Secret, err error, path string) (map[string]any, Metadata, error) {
+	if err != nil {
+		slog.Error("failed to generate credentials", "engine", engine.engine, "path", path, "error", err)
+		return map[string]any{}, Metadata{}, classifyResponse(err)
+	}
+
+	// initialize secret metadata
+	metadata, err := rawSecretToMetadata(rawSecret)
+	if err != nil {
+		slog.Error("raw secret could not be converted to metadata", "error", err)
+		return map[string]any{}, Metadata{}, err
+	}
+
+	// assign expiration time as version to metadata
+	metadata.Version = expirationVersion(metadata.LeaseDuration)
+
+	// return secret value implicitly coerced to map[string]any, expiration time as version, and metadata
+	return rawSecret.Data, metadata, nil
+}
+
+// credentials are generated and cannot be written
+func (engine credentialEngine) Write(ctx context.Context, client *vault.Client, mount string, path string, secretValue map[string]any, patch bool) (Metadata, error) {
+	slog.Error("secrets cannot be written to the secrets engine", "engine", engine.engine, "mount", mount, "path", path)
+	return Metadata{}, NewError(ErrInvalidConfig, errors.New("invalid secret engine"))
+}
+
+// renew dynamic secret lease and return updated metadata
+func (engine credentialEngine) Renew(ctx context.Context, client *vault.Client, mount string, path string, lease Lease) (Metadata, error) {
+	// determine full lease id from lease id suffix
+	leaseId := lease.ID
+	if !strings.Contains(leaseId, "/") {
+		leaseId = engine.credentialPath(mount, path) + "/" + leaseId
+	} else if !strings.HasPrefix(leaseId, mount+"/") {
+		slog.Error("the lease ID is not within the mount", "lease_id", leaseId, "mount", mount)
+		return Metadata{}, NewError(ErrInvalidConfig, errors.New("lease outside of mount"))
+	}
+
+	// renew the secret lease with the requested increment in seconds
+	rawSecret, err := client.Sys().RenewWithContext(ctx, leaseId, int(lease.Increment.Seconds()))
+	if err != nil {
+		slog.Error("the secret lease could not be renewed", "lease_id", leaseId, "error", err)
+		return Metadata{}, classifyResponse(err)
+	}
+	slog.Info("the secret lease was successfully renewed", "lease_id", leaseId)
+
+	// initialize secret metadata
+	metadata, err := rawSecretToMetadata(rawSecret)
+	if err != nil {
+		slog.Error("raw secret could not be converted to metadata", "error", err)
+		return Metadata{}, err
+	}
+
+	// assign expiration time as version to metadata
+	metadata.Version = expirationVersion(metadata.LeaseDuration)
+
+	return metadata, nil
+}
+
+// credentials are versioned only by lease expiration which requires generation or renewal
+func (engine credentialEngine) Version(ctx context.Context, client *vault.Client, mount string, path string) (string, error) {
+	slog.Error("the secrets engine generates credentials that are versioned only by lease expiration time", "engine", engine.engine)
+	return "", NewError(ErrInvalidConfig, errors.New("unversioned secret engine"))
+}
+
+// renew the credentials lease and return the updated expiration time as version (input version is ignored)
+func (engine credentialEngine) Check(ctx context.Context, client *vault.Client, mount string, path string, version string, options CheckOptions) ([]SecretVersion, error) {
+	slog.Info("the secret is dynamic and will be renewed", "engine", engine.engine, "mount", mount, "path", path)
+
+	metadata, err := engine.Renew(ctx, client, mount, path, options.Lease)
+	if err != nil {
+		slog.Error("failed to renew dynamic secret", "engine", engine.engine, "mount", mount, "path", path, "error", err)
+		return nil, err
+	}
+
+	return []SecretVersion{{Version: metadata.Version}}, nil
+}
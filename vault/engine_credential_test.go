@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+// test credential secret engine
+func TestCredentialEngine(test *testing.T) {
+	dbEngine := credentialEngine{engine: enum.Database}
+	if mount := dbEngine.DefaultMount(); mount != "database" {
+		test.Errorf("expected default mount: database, actual: %s", mount)
+	}
+
+	// credentials generation and renewal require a configured role
+	if util.Fake != nil {
+		credentials, secretMetadata, err := dbEngine.Read(context.Background(), util.VaultClient, "database", util.FakeCredentialRole, "")
+		if err != nil {
+			test.Error("credentials failed to generate")
+			test.Error(err)
+		}
+		if len(credentials) == 0 || !secretMetadata.Renewable || len(secretMetadata.LeaseID) == 0 {
+			test.Errorf("credentials generation returned unexpected values: %v, metadata: %v", credentials, secretMetadata)
+		}
+
+		// check renews with lease id suffix without generating credentials (which would issue a lease on every check)
+		leaseIdSuffix := secretMetadata.LeaseID[strings.LastIndex(secretMetadata.LeaseID, "/")+1:]
+		leaseIDs := util.Fake.LeaseIDs()
+		versions, err := dbEngine.Check(context.Background(), util.VaultClient, "database", util.FakeCredentialRole, "", CheckOptions{Lease: Lease{ID: leaseIdSuffix}})
+		if err != nil || len(versions) != 1 {
+			test.Errorf("credentials check returned unexpected versions: %v, error: %v", versions, err)
+		}
+		if checkLeaseIDs := util.Fake.LeaseIDs(); !slices.Equal(checkLeaseIDs, leaseIDs) {
+			test.Errorf("credentials check generated credentials, leases before: %v, after: %v", leaseIDs, checkLeaseIDs)
+		}
+
+		// renewal with full lease id and increment
+		renewedMetadata, err := dbEngine.Renew(context.Background(), util.VaultClient, "database", util.FakeCredentialRole, Lease{ID: secretMetadata.LeaseID, Increment: 30 * time.Minute})
+		if err != nil || renewedMetadata.LeaseID != secretMetadata.LeaseID || renewedMetadata.LeaseDuration != 30*time.Minute {
+			test.Errorf("credentials renewal with full lease id returned unexpected metadata: %v, error: %v", renewedMetadata, err)
+		}
+		if _, err = dbEngine.Renew(context.Background(), util.VaultClient, "database", util.FakeCredentialRole, Lease{ID: "aws/creds/readonly/" + leaseIdSuffix}); err == nil || err.Error() != "lease outside of mount" {
+			test.Errorf("expected error: lease outside of mount, actual: %v", err)
+		}
+
+		// generation and renewal for endpoint other than creds
+		awsEngine := secretEngines[enum.AWS].(credentialEngine)
+		_, stsMetadata, err := awsEngine.Read(context.Background(), util.VaultClient, "aws", "sts/"+util.FakeCredentialRole, "")
+		if err != nil || !strings.HasPrefix(stsMetadata.LeaseID, "aws/sts/"+util.FakeCredentialRole+"/") {
+			test.Errorf("sts credentials generation returned unexpected metadata: %v, error: %v", stsMetadata, err)
+		}
+		stsLeaseIdSuffix := stsMetadata.LeaseID[strings.LastIndex(stsMetadata.LeaseID, "/")+1:]
+		if _, err = awsEngine.Renew(context.Background(), util.VaultClient, "aws", "sts/"+util.FakeCredentialRole, Lease{ID: stsLeaseIdSuffix}); err != nil {
+			test.Errorf("sts credentials renewal with lease id suffix failed: %v", err)
+		}
+	}
+
+	// credential paths
+	for path, expectedPath := range map[string]string{
+		"readonly":              "database/creds/readonly",
+		"creds/readonly":        "database/creds/readonly",
+		"static-creds/readonly": "database/static-creds/readonly",
+		"sts/readonly":          "database/creds/sts/readonly",
+	} {
+		if credentialPath := secretEngines[enum.Database].(credentialEngine).credentialPath("database", path); credentialPath != expectedPath {
+			test.Errorf("expected credential path: %s, actual: %s", expectedPath, credentialPath)
+		}
+	}
+
+	// test errors
+	if _, err := dbEngine.Write(context.Background(), util.VaultClient, "database", util.KVPath, map[string]any{}, false); err == nil || err.Error() != "invalid secret engine" {
+		test.Errorf("expected error: invalid secret engine, actual: %v", err)
+	}
+	if _, err := dbEngine.Version(context.Background(), util.VaultClient, "database", util.KVPath); err == nil || err.Error() != "unversioned secret engine" {
+		test.Errorf("expected error: unversioned secret engine, actual: %v", err)
+	}
+	if _, _, err := dbEngine.Read(context.Background(), util.VaultClient, "database", "does-not-exist", ""); err == nil {
+		test.Error("expected error for credentials generation with nonexistent role")
+	}
+}
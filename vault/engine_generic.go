@@ -0,0 +1,98 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// generic secret engine for raw logical reads and writes of arbitrary paths
+type genericEngine struct {
+	staticEngine
+}
+
+func init() {
+	RegisterSecretEngine(enum.Generic, genericEngine{})
+}
+
+// mount is optional and path may be the full logical path
+func (genericEngine) DefaultMount() string {
+	return ""
+}
+
+// determine full logical path
+func logicalPath(mount string, path string) string {
+	if len(mount) == 0 {
+		return path
+	}
+	return mount + "/" + path
+}
+
+// read generic secret with raw logical read (version is ignored)
+func (genericEngine) Read(ctx context.Context, client *vault.Client, mount string, path string, version string) (map[string]any, Metadata, error) {
+	// read raw secret
+	rawSecret, err := client.Logical().ReadWithContext(ctx, logicalPath(mount, path))
+	if err != nil {
+		slog.Error("failed to read from the logical path", "engine", enum.Generic, "logical_path", logicalPath(mount, path), "error", err)
+		return map[string]any{}, Metadata{}, classifyResponse(err)
+	}
+	if rawSecret == nil {
+		slog.Error("no data exists at the logical path", "engine", enum.Generic, "logical_path", logicalPath(mount, path))
+		return map[string]any{}, Metadata{}, NewError(ErrNotFound, errors.New("no data at logical path"))
+	}
+
+	// initialize secret metadata with dummy version because logical paths are unversioned
+	metadata, err := rawSecretToMetadata(rawSecret)
+	if err != nil {
+		slog.Error("raw secret could not be converted to metadata", "error", err)
+		return map[string]any{}, Metadata{}, err
+	}
+
+	return rawSecret.Data, metadata, nil
+}
+
+// write generic secret with raw logical write
+func (genericEngine) Write(ctx context.Context, client *vault.Client, mount string, path string, body map[string]any, patch bool) (Metadata, error) {
+	if patch {
+		slog.Warn("patch is not supported with the generic secrets engine, and the input parameter will be ignored", "engine", enum.Generic, "logical_path", logicalPath(mount, path))
+	}
+
+	// write raw body
+	rawSecret, err := client.Logical().WriteWithContext(ctx, logicalPath(mount, path), body)
+	if err != nil {
+		slog.Error("failed to write to the logical path", "engine", enum.Generic, "logical_path", logicalPath(mount, path), "error", err)
+		return Metadata{}, classifyResponse(err)
+	}
+	// many endpoints return no content after a write
+	if rawSecret == nil {
+		rawSecret = &vault.Secret{}
+	}
+
+	// initialize secret metadata with dummy version because logical paths are unversioned
+	metadata, err := rawSecretToMetadata(rawSecret)
+	if err != nil {
+		slog.Error("raw secret could not be converted to metadata", "error", err)
+		return Metadata{}, err
+	}
+
+	return metadata, nil
+}
+
+// logical paths are unversioned so return dummy version
+func (genericEngine) Version(ctx context.Context, client *vault.Client, mount string, path string) (string, error) {
+	return "0", nil
+}
+
+// logical paths are versioned by digest (reading a path with side effects such as credential generation is the responsibility of the pipeline)
+func (genericEngine) DigestVersioned() bool {
+	return true
+}
+
+// logical paths are unversioned so return digest version of the secret value (input version is ignored)
+func (engine genericEngine) Check(ctx context.Context, client *vault.Client, mount string, path string, version string, options CheckOptions) ([]SecretVersion, error) {
+	return digestCheck(ctx, client, engine, mount, path, options.DigestKey)
+}
@@ -0,0 +1,44 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+// test generic secret engine logical write and read
+func TestGenericEngine(test *testing.T) {
+	if _, err := (genericEngine{}).Write(context.Background(), util.VaultClient, util.KV1Mount, "generic", map[string]any{util.KVKey: util.KVValue}, false); err != nil {
+		test.Error("the generic secret was not successfully written")
+		test.Error(err)
+	}
+
+	genericValue, secretMetadata, err := genericEngine{}.Read(context.Background(), util.VaultClient, util.KV1Mount, "generic", "")
+	if err != nil {
+		test.Error("the generic secret was not successfully read")
+		test.Error(err)
+	}
+	if secretMetadata.Version != "0" {
+		test.Errorf("the generic secret read returned non-zero version: %s", secretMetadata.Version)
+	}
+	if genericValue[util.KVKey] != util.KVValue {
+		test.Error("the read generic secret value was incorrect")
+		test.Errorf("secret map value: %v", genericValue)
+	}
+
+	// test errors
+	if _, _, err = (genericEngine{}).Read(context.Background(), util.VaultClient, util.KV1Mount, "does/not/exist", ""); err == nil || err.Error() != "no data at logical path" {
+		test.Errorf("expected error: no data at logical path, actual: %v", err)
+	}
+}
+
+// test logical path
+func TestLogicalPath(test *testing.T) {
+	if path := logicalPath("", "sys/mounts"); path != "sys/mounts" {
+		test.Errorf("expected logical path: sys/mounts, actual: %s", path)
+	}
+	if path := logicalPath("kv", "foo/bar"); path != "kv/foo/bar" {
+		test.Errorf("expected logical path: kv/foo/bar, actual: %s", path)
+	}
+}
@@ -0,0 +1,114 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// key-value version 1 secret engine
+type kv1Engine struct {
+	staticEngine
+}
+
+func init() {
+	RegisterSecretEngine(enum.KeyValue1, kv1Engine{})
+}
+
+func (kv1Engine) DefaultMount() string {
+	return "kv"
+}
+
+// retrieve key-value v1 pair secrets
+func (engine kv1Engine) Read(ctx context.Context, client *vault.Client, mount string, path string, version string) (map[string]any, Metadata, error) {
+	if len(version) > 0 {
+		slog.Warn("versions cannot be used with the KV1 secrets engine, and the input parameter will be ignored", "engine", enum.KeyValue1, "mount", mount, "path", path, "version", version)
+	}
+
+	secretValue, metadata, err := engine.readLatest(ctx, client, mount, path)
+	if errors.Is(err, ErrNotFound) {
+		slog.Error("no secret exists", "engine", enum.KeyValue1, "mount", mount, "path", path)
+	}
+
+	return secretValue, metadata, err
+}
+
+// retrieve key-value v1 pair secrets without logging a nonexistent secret
+func (kv1Engine) readLatest(ctx context.Context, client *vault.Client, mount string, path string) (map[string]any, Metadata, error) {
+	// read kv secret
+	kvSecret, err := client.KVv1(mount).Get(ctx, path)
+	if err != nil {
+		err = classifyResponse(err)
+		// a nonexistent secret is logged by the caller
+		if !errors.Is(err, ErrNotFound) {
+			slog.Error("failed to read secret", "engine", enum.KeyValue1, "mount", mount, "path", path, "error", err)
+		}
+		// return empty values since error triggers at end of execution
+		return map[string]any{}, Metadata{}, err
+	}
+	if kvSecret == nil {
+		return map[string]any{}, Metadata{}, NewError(ErrNotFound, errors.New("secret not found"))
+	}
+
+	// initialize secret metadata with dummy version
+	metadata, err := rawSecretToMetadata(kvSecret.Raw)
+	if err != nil {
+		slog.Error("raw secret could not be converted to metadata", "error", err)
+		return map[string]any{}, Metadata{}, err
+	}
+
+	// return secret value and implicitly coerce type to map[string]any
+	return kvSecret.Data, metadata, nil
+}
+
+// populate key-value v1 pair secrets
+func (engine kv1Engine) Write(ctx context.Context, client *vault.Client, mount string, path string, secretValue map[string]any, patch bool) (Metadata, error) {
+	// skip the write if the secret value is unchanged
+	if metadata, unchanged := unchangedSecretValue(ctx, client, engine, mount, path, secretValue, patch); unchanged {
+		return metadata, nil
+	}
+
+	// put kv1 secret
+	if err := client.KVv1(mount).Put(ctx, path, secretValue); err != nil {
+		slog.Error("failed to update secret", "engine", enum.KeyValue1, "mount", mount, "path", path, "error", err)
+		return Metadata{}, classifyResponse(err)
+	}
+
+	// initialize secret metadata with dummy version
+	metadata, err := rawSecretToMetadata(&vault.Secret{})
+	if err != nil {
+		slog.Error("raw secret could not be converted to metadata", "error", err)
+		return Metadata{}, err
+	}
+
+	return metadata, nil
+}
+
+// list kv1 secrets beneath the prefix
+func (kv1Engine) ListPath(mount string, prefix string) string {
+	return mount + "/" + prefix
+}
+
+// compare kv1 secret value to the current secret value (patch is ignored)
+func (engine kv1Engine) Diff(ctx context.Context, client *vault.Client, mount string, path string, secretValue map[string]any, patch bool) (SecretValueDiff, Metadata, error) {
+	return diffCurrentSecretValue(ctx, client, engine, mount, path, secretValue, false)
+}
+
+// kv1 secrets are versioned by digest
+func (kv1Engine) DigestVersioned() bool {
+	return true
+}
+
+// kv1 secrets are unversioned so return dummy version
+func (kv1Engine) Version(ctx context.Context, client *vault.Client, mount string, path string) (string, error) {
+	return "0", nil
+}
+
+// kv1 secrets are unversioned so return digest version of the secret value (input version is ignored)
+func (engine kv1Engine) Check(ctx context.Context, client *vault.Client, mount string, path string, version string, options CheckOptions) ([]SecretVersion, error) {
+	return digestCheck(ctx, client, engine, mount, path, options.DigestKey)
+}
@@ -0,0 +1,90 @@
+package vault
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+// test kv1 secret engine read
+func TestKV1EngineRead(test *testing.T) {
+	kv1Value, secretMetadata, err := kv1Engine{}.Read(context.Background(), util.VaultClient, util.KV1Mount, util.KVPath, "")
+	if err != nil {
+		test.Error("kv1 secret retrieval failed")
+		test.Error(err)
+	}
+	if secretMetadata == (Metadata{}) {
+		test.Error("the kv1 secret retrieval returned empty metadata")
+	}
+	if secretMetadata.Version != "0" {
+		test.Errorf("the kv1 secret retrieval returned non-zero version: %s", secretMetadata.Version)
+	}
+	if kv1Value[util.KVKey] != util.KVValue {
+		test.Error("the retrieved kv1 secret value was incorrect")
+		test.Errorf("secret map value: %v", kv1Value)
+	}
+}
+
+// test kv1 secret engine write
+func TestKV1EngineWrite(test *testing.T) {
+	secretMetadata, err := kv1Engine{}.Write(
+		context.Background(),
+		util.VaultClient,
+		util.KV1Mount,
+		util.KVPath,
+		map[string]any{util.KVKey: util.KVValue},
+		false,
+	)
+	if err != nil {
+		test.Error("the kv1 secret was not successfully put")
+		test.Error(err)
+	}
+	if secretMetadata == (Metadata{}) {
+		test.Error("the kv1 secret retrieval returned empty metadata")
+	}
+	if secretMetadata.Version != "0" {
+		test.Errorf("the kv1 secret put returned non-zero version: %s", secretMetadata.Version)
+	}
+
+	// unchanged secret value is not written
+	secretValue := map[string]any{util.KVKey: util.KVValue}
+	if secretMetadata, err = (kv1Engine{}).Write(context.Background(), util.VaultClient, util.KV1Mount, "unchanged", secretValue, false); err != nil || secretMetadata.Unchanged {
+		test.Errorf("expected kv1 secret to be written, actual metadata: %v, error: %v", secretMetadata, err)
+	}
+	if secretMetadata, err = (kv1Engine{}).Write(context.Background(), util.VaultClient, util.KV1Mount, "unchanged", secretValue, false); err != nil || secretMetadata != (Metadata{Version: "0", Unchanged: true}) {
+		test.Errorf("expected unchanged kv1 secret not to be written, actual metadata: %v, error: %v", secretMetadata, err)
+	}
+	if secretMetadata, err = (kv1Engine{}).Write(context.Background(), util.VaultClient, util.KV1Mount, "unchanged", map[string]any{util.KVKey: "changed"}, false); err != nil || secretMetadata.Unchanged {
+		test.Errorf("expected changed kv1 secret to be written, actual metadata: %v, error: %v", secretMetadata, err)
+	}
+}
+
+// test kv1 secret engine check
+func TestKV1EngineCheck(test *testing.T) {
+	if versions, err := (kv1Engine{}).Check(context.Background(), util.VaultClient, util.KV1Mount, util.KVPath, "", CheckOptions{}); err != nil || !slices.Equal(versions, []SecretVersion{{Version: "0"}}) {
+		test.Errorf("expected kv1 check versions: [0], actual: %v, error: %v", versions, err)
+	}
+
+	// digest version changes with secret value
+	digestOptions := CheckOptions{DigestKey: []byte("hmackey")}
+	versions, err := (kv1Engine{}).Check(context.Background(), util.VaultClient, util.KV1Mount, "digest", "", digestOptions)
+	if err == nil {
+		test.Errorf("expected error for digest version of nonexistent secret, actual versions: %v", versions)
+	}
+	(kv1Engine{}).Write(context.Background(), util.VaultClient, util.KV1Mount, "digest", map[string]any{util.KVKey: util.KVValue}, false)
+	versions, err = (kv1Engine{}).Check(context.Background(), util.VaultClient, util.KV1Mount, "digest", "", digestOptions)
+	if err != nil || len(versions) != 1 || len(versions[0].Version) != 64 {
+		test.Errorf("unexpected kv1 digest versions: %v, error: %v", versions, err)
+	}
+	(kv1Engine{}).Write(context.Background(), util.VaultClient, util.KV1Mount, "digest", map[string]any{util.KVKey: "changed"}, false)
+	if changedVersions, err := (kv1Engine{}).Check(context.Background(), util.VaultClient, util.KV1Mount, "digest", "", digestOptions); err != nil || slices.Equal(changedVersions, versions) {
+		test.Errorf("kv1 digest version did not change with secret value: %v, error: %v", changedVersions, err)
+	}
+
+	// test errors
+	if _, err := (kv1Engine{}).Renew(context.Background(), util.VaultClient, util.KV1Mount, util.KVPath, Lease{}); err == nil || err.Error() != "non-renewable secret" {
+		test.Errorf("expected error: non-renewable secret, actual: %v", err)
+	}
+}
@@ -0,0 +1,217 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// key-value version 2 secret engine
+type kv2Engine struct {
+	staticEngine
+}
+
+func init() {
+	RegisterSecretEngine(enum.KeyValue2, kv2Engine{})
+}
+
+func (kv2Engine) DefaultMount() string {
+	return "secret"
+}
+
+// retrieve key-value v2 pair secrets
+func (engine kv2Engine) Read(ctx context.Context, client *vault.Client, mount string, path string, version string) (map[string]any, Metadata, error) {
+	secretValue, metadata, err := engine.read(ctx, client, mount, path, version)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrVersionMissing) {
+		slog.Error("no secret exists for the version (empty signifies latest)", "engine", enum.KeyValue2, "mount", mount, "path", path, "version", version, "error", err)
+	}
+
+	return secretValue, metadata, err
+}
+
+// retrieve latest key-value v2 pair secrets without logging a nonexistent secret
+func (engine kv2Engine) readLatest(ctx context.Context, client *vault.Client, mount string, path string) (map[string]any, Metadata, error) {
+	return engine.read(ctx, client, mount, path, "")
+}
+
+// retrieve key-value v2 pair secrets without logging a nonexistent secret or version
+func (kv2Engine) read(ctx context.Context, client *vault.Client, mount string, path string, version string) (map[string]any, Metadata, error) {
+	// declare error and kvSecret for metadata.version and raw secret assignments and returns
+	var err error
+	var kvSecret *vault.KVSecret
+
+	if len(version) == 0 {
+		// read latest kv2 secret
+		kvSecret, err = client.KVv2(mount).Get(ctx, path)
+	} else {
+		// validate version if input
+		versionInt, convErr := strconv.Atoi(version)
+		if convErr != nil {
+			slog.Error("KV2 version must be an integer", "engine", enum.KeyValue2, "mount", mount, "path", path, "version", version, "error", convErr)
+			// return empty values since error triggers at end of execution
+			return map[string]any{}, Metadata{}, NewError(ErrInvalidConfig, convErr)
+		}
+
+		// read specific version of kv2 secret
+		kvSecret, err = client.KVv2(mount).GetVersion(ctx, path, versionInt)
+	}
+
+	// verify secret read
+	if err != nil {
+		// a specified version that is not found is missing, and is logged by the caller
+		if len(version) > 0 && errors.Is(err, vault.ErrSecretNotFound) {
+			return map[string]any{}, Metadata{}, NewError(ErrVersionMissing, err)
+		}
+		// a nonexistent secret is logged by the caller
+		if err = classifyResponse(err); !errors.Is(err, ErrNotFound) {
+			slog.Error("failed to read secret for version (empty signifies latest)", "engine", enum.KeyValue2, "mount", mount, "path", path, "version", version, "error", err)
+		}
+		// return empty values since error triggers at end of execution
+		return map[string]any{}, Metadata{}, err
+	}
+	if kvSecret == nil {
+		return map[string]any{}, Metadata{}, NewError(ErrNotFound, errors.New("secret not found"))
+	}
+
+	// initialize secret metadata
+	metadata, err := rawSecretToMetadata(kvSecret.Raw)
+	if err != nil {
+		slog.Error("raw secret could not be converted to metadata", "error", err)
+		return map[string]any{}, Metadata{}, err
+	}
+
+	if kvSecret.Data == nil { // verify version exists (deleted or destroyed)
+		// return partial information values since error triggers at end of execution
+		metadata.Version = version
+		return map[string]any{}, metadata, NewError(ErrVersionMissing, errors.New("secret version does not exist"))
+	}
+
+	// return secret value and implicitly coerce type to map[string]any
+	metadata.Version = strconv.Itoa(kvSecret.VersionMetadata.Version)
+	return kvSecret.Data, metadata, nil
+}
+
+// populate key-value v2 pair secrets
+func (engine kv2Engine) Write(ctx context.Context, client *vault.Client, mount string, path string, secretValue map[string]any, patch bool) (Metadata, error) {
+	// skip the write if the secret value is unchanged so that a new version is not created
+	if metadata, unchanged := unchangedSecretValue(ctx, client, engine, mount, path, secretValue, patch); unchanged {
+		return metadata, nil
+	}
+
+	// declare error and kvSecret for return to cmd
+	var err error
+	var kvSecret *vault.KVSecret
+
+	if patch {
+		// patch kv2 secret
+		kvSecret, err = client.KVv2(mount).Patch(ctx, path, secretValue)
+	} else {
+		// put kv2 secret
+		kvSecret, err = client.KVv2(mount).Put(ctx, path, secretValue)
+	}
+
+	// verify secret patch/put
+	if err != nil {
+		slog.Error("failed to update secret", "engine", enum.KeyValue2, "mount", mount, "path", path, "error", err)
+		return Metadata{}, classifyResponse(err)
+	}
+
+	// initialize secret metadata and assign version
+	metadata, err := rawSecretToMetadata(kvSecret.Raw)
+	if err != nil {
+		slog.Error("raw secret could not be converted to metadata", "error", err)
+		return Metadata{}, err
+	}
+	metadata.Version = strconv.Itoa(kvSecret.VersionMetadata.Version)
+
+	return metadata, nil
+}
+
+// list kv2 secrets beneath the prefix from the secret metadata
+func (kv2Engine) ListPath(mount string, prefix string) string {
+	return mount + "/metadata/" + prefix
+}
+
+// compare kv2 secret value (merged into the current secret value if patch) to the current secret value
+func (engine kv2Engine) Diff(ctx context.Context, client *vault.Client, mount string, path string, secretValue map[string]any, patch bool) (SecretValueDiff, Metadata, error) {
+	return diffCurrentSecretValue(ctx, client, engine, mount, path, secretValue, patch)
+}
+
+// kv2 secrets are natively versioned
+func (kv2Engine) DigestVersioned() bool {
+	return false
+}
+
+// return latest version of kv2 secret
+func (engine kv2Engine) Version(ctx context.Context, client *vault.Client, mount string, path string) (string, error) {
+	_, metadata, err := engine.Read(ctx, client, mount, path, "")
+	if err != nil {
+		slog.Error("the latest version could not be retrieved for the secret", "engine", enum.KeyValue2, "mount", mount, "path", path, "error", err)
+		return "", err
+	}
+
+	return metadata.Version, nil
+}
+
+// return existing versions of kv2 secret from input version through latest version inclusive from the version metadata without reading secret data
+func (kv2Engine) Check(ctx context.Context, client *vault.Client, mount string, path string, version string, options CheckOptions) ([]SecretVersion, error) {
+	// validate input version
+	inputVersion := 0
+	if len(version) > 0 {
+		var err error
+		if inputVersion, err = strconv.Atoi(version); err != nil {
+			slog.Error("the input version in source is not a valid integer", "engine", enum.KeyValue2, "mount", mount, "path", path, "version", version, "error", err)
+			return nil, NewError(ErrInvalidConfig, err)
+		}
+	}
+
+	// retrieve version metadata sorted by version
+	versionsMetadata, err := client.KVv2(mount).GetVersionsAsList(ctx, path)
+	if err != nil {
+		slog.Error("failed to retrieve version metadata for the secret", "engine", enum.KeyValue2, "mount", mount, "path", path, "error", err)
+		return nil, classifyResponse(err)
+	}
+
+	// collect versions that are neither soft-deleted (deletion time may be scheduled in the future) nor destroyed
+	existingVersions := []SecretVersion{}
+	for _, versionMetadata := range versionsMetadata {
+		if versionMetadata.Destroyed || (!versionMetadata.DeletionTime.IsZero() && versionMetadata.DeletionTime.Before(time.Now())) {
+			slog.Info("the secret version is deleted or destroyed and will be skipped", "engine", enum.KeyValue2, "mount", mount, "path", path, "version", versionMetadata.Version)
+			continue
+		}
+		existingVersions = append(existingVersions, SecretVersion{Version: strconv.Itoa(versionMetadata.Version), CreatedTime: versionMetadata.CreatedTime})
+	}
+	if len(existingVersions) == 0 {
+		slog.Error("all versions of the secret are deleted or destroyed", "engine", enum.KeyValue2, "mount", mount, "path", path)
+		return nil, NewError(ErrVersionMissing, errors.New("no existing secret versions"))
+	}
+	latestVersion := existingVersions[len(existingVersions)-1]
+
+	// only the latest version is returned if no input version
+	if inputVersion == 0 {
+		return []SecretVersion{latestVersion}, nil
+	}
+
+	// populate versions slice with delta
+	versions := []SecretVersion{}
+	for _, existingVersion := range existingVersions {
+		if versionInt, _ := strconv.Atoi(existingVersion.Version); versionInt >= inputVersion {
+			versions = append(versions, existingVersion)
+		}
+	}
+
+	// validate that the input version is <= the latest existing version
+	if len(versions) == 0 {
+		slog.Warn("the input version is later than the latest existing version, and only the latest existing version will be returned to Concourse", "engine", enum.KeyValue2, "mount", mount, "path", path, "version", inputVersion, "latest_version", latestVersion.Version)
+
+		return []SecretVersion{latestVersion}, nil
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,185 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+// test kv2 secret engine read
+func TestKV2EngineRead(test *testing.T) {
+	kv2Value, secretMetadata, err := kv2Engine{}.Read(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath, "")
+	if err != nil {
+		test.Error("kv2 secret retrieval failed")
+		test.Error(err)
+	}
+	if secretMetadata == (Metadata{}) {
+		test.Error("the kv2 secret retrieval returned empty metadata")
+	}
+	if secretMetadata.Version == "0" {
+		test.Errorf("the kv2 secret retrieval returned an invalid version: %s", secretMetadata.Version)
+	}
+	if kv2Value[util.KVKey] != util.KVValue {
+		test.Error("the retrieved kv2 secret value was incorrect")
+		test.Errorf("secret map value: %v", kv2Value)
+	}
+
+	if _, secretMetadata, err = (kv2Engine{}).Read(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath, "1"); err != nil || secretMetadata.Version != "1" {
+		test.Errorf("expected kv2 secret retrieval of version 1, actual: %s, error: %v", secretMetadata.Version, err)
+	}
+
+	// test errors
+	if _, _, err = (kv2Engine{}).Read(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath, "one"); err == nil {
+		test.Error("expected error for non-integer kv2 version")
+	}
+	if _, _, err = (kv2Engine{}).Read(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath, "1000"); !errors.Is(err, ErrVersionMissing) {
+		test.Errorf("expected missing version error for nonexistent kv2 version, actual: %v", err)
+	}
+	if _, _, err = (kv2Engine{}).Read(context.Background(), util.VaultClient, util.KV2Mount, "does/not/exist", ""); !errors.Is(err, ErrNotFound) {
+		test.Errorf("expected not found error for nonexistent kv2 secret, actual: %v", err)
+	}
+}
+
+// test kv2 secret engine write
+func TestKV2EngineWrite(test *testing.T) {
+	secretMetadata, err := kv2Engine{}.Write(
+		context.Background(),
+		util.VaultClient,
+		util.KV2Mount,
+		util.KVPath,
+		map[string]any{util.KVKey: util.KVValue},
+		false,
+	)
+	if err != nil {
+		test.Error("the kv2 secret was not successfully put")
+		test.Error(err)
+	}
+	if secretMetadata == (Metadata{}) {
+		test.Error("the kv2 secret put returned empty metadata")
+	}
+	if secretMetadata.Version == "0" {
+		test.Errorf("the kv2 secret put returned an invalid version: %s", secretMetadata.Version)
+	}
+
+	secretMetadata, err = kv2Engine{}.Write(
+		context.Background(),
+		util.VaultClient,
+		util.KV2Mount,
+		util.KVPath,
+		map[string]any{"other_password": "ultrasecret"},
+		true,
+	)
+	if err != nil {
+		test.Error("the kv2 secret was not successfully patched")
+		test.Error(err)
+	}
+	if secretMetadata == (Metadata{}) {
+		test.Error("the kv2 secret patch returned empty metadata")
+	}
+	if secretMetadata.Version == "0" {
+		test.Errorf("the kv2 secret patch returned an invalid version: %s", secretMetadata.Version)
+	}
+
+	// unchanged secret value is not written as a new version
+	secretValue := map[string]any{util.KVKey: util.KVValue, "other_password": "ultrasecret"}
+	firstMetadata, err := kv2Engine{}.Write(context.Background(), util.VaultClient, util.KV2Mount, "unchanged", secretValue, false)
+	if err != nil || firstMetadata.Unchanged {
+		test.Errorf("expected kv2 secret to be written, actual metadata: %v, error: %v", firstMetadata, err)
+	}
+	for _, patch := range []bool{false, true} {
+		if secretMetadata, err = (kv2Engine{}).Write(context.Background(), util.VaultClient, util.KV2Mount, "unchanged", map[string]any{util.KVKey: util.KVValue, "other_password": "ultrasecret"}, patch); err != nil || secretMetadata != (Metadata{Version: firstMetadata.Version, Unchanged: true}) {
+			test.Errorf("expected unchanged kv2 secret with patch %t not to be written, actual metadata: %v, error: %v", patch, secretMetadata, err)
+		}
+	}
+	// patch of a subset of the current keys is unchanged
+	if secretMetadata, err = (kv2Engine{}).Write(context.Background(), util.VaultClient, util.KV2Mount, "unchanged", map[string]any{util.KVKey: util.KVValue}, true); err != nil || !secretMetadata.Unchanged {
+		test.Errorf("expected unchanged kv2 secret patch not to be written, actual metadata: %v, error: %v", secretMetadata, err)
+	}
+	// patch of a nested value is merged recursively
+	nestedValue := map[string]any{"db": map[string]any{"user": "admin", "password": "old"}}
+	if _, err = (kv2Engine{}).Write(context.Background(), util.VaultClient, util.KV2Mount, "unchanged/nested", nestedValue, false); err != nil {
+		test.Fatal(err)
+	}
+	if secretMetadata, err = (kv2Engine{}).Write(context.Background(), util.VaultClient, util.KV2Mount, "unchanged/nested", map[string]any{"db": map[string]any{"password": "old"}}, true); err != nil || !secretMetadata.Unchanged {
+		test.Errorf("expected unchanged nested kv2 secret patch not to be written, actual metadata: %v, error: %v", secretMetadata, err)
+	}
+	if secretMetadata, err = (kv2Engine{}).Write(context.Background(), util.VaultClient, util.KV2Mount, "unchanged/nested", map[string]any{"db": map[string]any{"password": "new"}}, true); err != nil || secretMetadata.Unchanged {
+		test.Errorf("expected changed nested kv2 secret patch to be written, actual metadata: %v, error: %v", secretMetadata, err)
+	}
+	if nestedSecret, _, err := (kv2Engine{}).Read(context.Background(), util.VaultClient, util.KV2Mount, "unchanged/nested", ""); err != nil || !reflect.DeepEqual(nestedSecret, map[string]any{"db": map[string]any{"user": "admin", "password": "new"}}) {
+		test.Errorf("expected nested kv2 secret patch to be merged recursively, actual: %v, error: %v", nestedSecret, err)
+	}
+
+	// put of a subset of the current keys removes keys
+	if secretMetadata, err = (kv2Engine{}).Write(context.Background(), util.VaultClient, util.KV2Mount, "unchanged", map[string]any{util.KVKey: util.KVValue}, false); err != nil || secretMetadata.Unchanged || secretMetadata.Version == firstMetadata.Version {
+		test.Errorf("expected changed kv2 secret to be written as a new version, actual metadata: %v, error: %v", secretMetadata, err)
+	}
+}
+
+// test kv2 secret engine version and check
+func TestKV2EngineCheck(test *testing.T) {
+	latestVersion, err := kv2Engine{}.Version(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath)
+	if err != nil {
+		test.Error("kv2 secret version retrieval failed")
+		test.Error(err)
+	}
+	latestVersionInt, _ := strconv.Atoi(latestVersion)
+
+	versions, err := kv2Engine{}.Check(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath, "1", CheckOptions{})
+	if err != nil || len(versions) != latestVersionInt || versions[0].Version != "1" || versions[len(versions)-1].Version != latestVersion || versions[0].CreatedTime.IsZero() {
+		test.Errorf("expected kv2 check versions from 1 through %s with creation times, actual: %v, error: %v", latestVersion, versions, err)
+	}
+
+	if versions, err = (kv2Engine{}).Check(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath, "", CheckOptions{}); err != nil || len(versions) != 1 || versions[0].Version != latestVersion {
+		test.Errorf("expected kv2 check versions: [%s], actual: %v, error: %v", latestVersion, versions, err)
+	}
+
+	if versions, err = (kv2Engine{}).Check(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath, "1000000", CheckOptions{}); err != nil || len(versions) != 1 || versions[0].Version != latestVersion {
+		test.Errorf("expected kv2 check versions: [%s], actual: %v, error: %v", latestVersion, versions, err)
+	}
+
+	// deleted and destroyed versions are skipped
+	for index := range 4 {
+		if _, err = (kv2Engine{}).Write(context.Background(), util.VaultClient, util.KV2Mount, "check/deleted", map[string]any{util.KVKey: strconv.Itoa(index)}, false); err != nil {
+			test.Fatal(err)
+		}
+	}
+	if err = util.VaultClient.KVv2(util.KV2Mount).DeleteVersions(context.Background(), "check/deleted", []int{2, 4}); err != nil {
+		test.Fatal(err)
+	}
+	if err = util.VaultClient.KVv2(util.KV2Mount).Destroy(context.Background(), "check/deleted", []int{3}); err != nil {
+		test.Fatal(err)
+	}
+	versionNumbers := func(versions []SecretVersion) []string {
+		numbers := []string{}
+		for _, version := range versions {
+			numbers = append(numbers, version.Version)
+		}
+		return numbers
+	}
+	for inputVersion, expectedVersions := range map[string][]string{"": {"1"}, "1": {"1"}, "2": {"1"}} {
+		if versions, err = (kv2Engine{}).Check(context.Background(), util.VaultClient, util.KV2Mount, "check/deleted", inputVersion, CheckOptions{}); err != nil || !slices.Equal(versionNumbers(versions), expectedVersions) {
+			test.Errorf("expected kv2 check versions for input version '%s': %v, actual: %v, error: %v", inputVersion, expectedVersions, versions, err)
+		}
+	}
+	(kv2Engine{}).Write(context.Background(), util.VaultClient, util.KV2Mount, "check/deleted", map[string]any{util.KVKey: "5"}, false)
+	if versions, err = (kv2Engine{}).Check(context.Background(), util.VaultClient, util.KV2Mount, "check/deleted", "1", CheckOptions{}); err != nil || !slices.Equal(versionNumbers(versions), []string{"1", "5"}) {
+		test.Errorf("expected kv2 check versions: [1 5], actual: %v, error: %v", versions, err)
+	}
+
+	// test errors
+	if _, err = (kv2Engine{}).Check(context.Background(), util.VaultClient, util.KV2Mount, util.KVPath, "one", CheckOptions{}); err == nil {
+		test.Error("expected error for invalid input version")
+	}
+	if err = util.VaultClient.KVv2(util.KV2Mount).DeleteVersions(context.Background(), "check/deleted", []int{1, 5}); err != nil {
+		test.Fatal(err)
+	}
+	if _, err = (kv2Engine{}).Check(context.Background(), util.VaultClient, util.KV2Mount, "check/deleted", "", CheckOptions{}); err == nil || err.Error() != "no existing secret versions" {
+		test.Errorf("expected error: no existing secret versions, actual: %v", err)
+	}
+}
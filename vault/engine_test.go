@@ -0,0 +1,107 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/concourse"
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// minimal secret engine for registry testing
+type testEngine struct {
+	genericEngine
+}
+
+func (testEngine) DefaultMount() string {
+	return "test"
+}
+
+func (testEngine) Version(ctx context.Context, client *vault.Client, mount string, path string) (string, error) {
+	return "1", nil
+}
+
+// test secret engine registration and lookup
+func TestRegisterSecretEngine(test *testing.T) {
+	RegisterSecretEngine("test", testEngine{})
+	defer delete(secretEngines, "test")
+
+	testVaultSecret, err := NewVaultSecret("test", "", "foo")
+	if err != nil {
+		test.Error("secret with registered test engine failed to construct")
+		test.Error(err)
+	}
+	if testVaultSecret.mount != "test" {
+		test.Errorf("expected default mount of registered test engine: test, actual: %s", testVaultSecret.mount)
+	}
+	if version, _ := testVaultSecret.Version(context.Background(), nil); version != "1" {
+		test.Errorf("expected version of registered test engine: 1, actual: %s", version)
+	}
+
+	// all enums are registered
+	for _, engine := range []enum.SecretEngine{enum.Database, enum.AWS, enum.Azure, enum.Consul, enum.Kubernetes, enum.Nomad, enum.RabbitMQ, enum.SSH, enum.Terraform, enum.KeyValue1, enum.KeyValue2, enum.Generic} {
+		if _, err := lookupSecretEngine(engine); err != nil {
+			test.Errorf("the secret engine %s is not registered", engine)
+		}
+	}
+
+	// test errors
+	if _, err = lookupSecretEngine("foo"); err == nil || err.Error() != "invalid secret engine" {
+		test.Errorf("expected error: invalid secret engine, actual: %v", err)
+	}
+}
+
+// test secret engine capabilities
+func TestSecretEngineCapabilities(test *testing.T) {
+	for engine, expected := range map[enum.SecretEngine]bool{enum.KeyValue1: true, enum.KeyValue2: false, enum.Generic: true, enum.Database: false, "foo": false} {
+		if DigestVersioned(engine) != expected {
+			test.Errorf("expected digest versioned for %s: %t", engine, expected)
+		}
+	}
+
+	for _, engine := range []enum.SecretEngine{enum.KeyValue1, enum.KeyValue2} {
+		if err := ValidateDiff(engine); err != nil {
+			test.Errorf("expected diff support for %s, actual error: %v", engine, err)
+		}
+	}
+	for _, engine := range []enum.SecretEngine{enum.Generic, enum.Database} {
+		if err := ValidateDiff(engine); !errors.Is(err, ErrInvalidConfig) || err.Error() != "diff unsupported for secret engine" {
+			test.Errorf("expected error: diff unsupported for secret engine, actual: %v", err)
+		}
+	}
+
+	if err := ValidateCompositeSecrets(concourse.Source{HMACKey: "key", Secrets: []concourse.CompositeSecret{{Engine: enum.KeyValue2, Mount: "secret", Path: "app", Prefix: true}, {Engine: enum.Generic, Mount: "sys", Path: "mounts"}}}); err != nil {
+		test.Errorf("expected valid composite secrets, actual error: %v", err)
+	}
+	for expectedErr, compositeSecret := range map[string]concourse.CompositeSecret{
+		"invalid composite secret engine":                    {Engine: enum.AWS, Mount: "aws", Path: "readonly"},
+		"invalid secret engine":                              {Engine: "foo", Mount: "foo", Path: "bar"},
+		"hmac key required for unversioned composite secret": {Engine: enum.KeyValue1, Mount: "kv", Path: "bar"},
+		"prefix specified with unlistable engine":            {Engine: enum.Generic, Mount: "sys", Prefix: true},
+	} {
+		hmacKey := "key"
+		if compositeSecret.Engine == enum.KeyValue1 {
+			hmacKey = ""
+		}
+		if err := ValidateCompositeSecrets(concourse.Source{HMACKey: hmacKey, Secrets: []concourse.CompositeSecret{compositeSecret}}); !errors.Is(err, ErrInvalidConfig) || err.Error() != expectedErr {
+			test.Errorf("expected error: %s, actual: %v", expectedErr, err)
+		}
+	}
+}
+
+// test expiration version
+func TestExpirationVersion(test *testing.T) {
+	version := expirationVersion(time.Hour)
+	expirationTime, err := time.ParseInLocation("2006-01-02-150405", version, time.Local)
+	if err != nil {
+		test.Error("the expiration version is not formatted as expected")
+		test.Error(err)
+	}
+	if delta := time.Until(expirationTime); delta < 59*time.Minute || delta > time.Hour {
+		test.Errorf("the expiration version %s is not approximately one hour from now", version)
+	}
+}
@@ -0,0 +1,101 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// kinds of classified errors for distinguishing permission and configuration problems from outages with errors.Is
+var (
+	ErrInvalidConfig    = errors.New("invalid configuration")
+	ErrAuthFailure      = errors.New("vault authentication failure")
+	ErrPermissionDenied = errors.New("vault permission denied")
+	ErrNotFound         = errors.New("vault secret not found")
+	ErrVersionMissing   = errors.New("vault secret version missing")
+	ErrSealed           = errors.New("vault sealed")
+	ErrUnavailable      = errors.New("vault unavailable")
+)
+
+// Error classifies an underlying error (e.g. a Vault api ResponseError) by kind, and retains the message of the underlying error
+type Error struct {
+	// kind of error (e.g. ErrPermissionDenied)
+	Kind error
+	// http status code of the Vault response (zero signifies no response)
+	StatusCode int
+	// underlying error
+	Err error
+}
+
+func (err *Error) Error() string {
+	return err.Err.Error()
+}
+
+// both the kind and the underlying error are matched by errors.Is and errors.As
+func (err *Error) Unwrap() []error {
+	return []error{err.Kind, err.Err}
+}
+
+// classified error constructor with the status code of a wrapped Vault response error
+func NewError(kind error, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// retain existing classification
+	var classifiedErr *Error
+	if errors.As(err, &classifiedErr) {
+		return err
+	}
+
+	statusCode := 0
+	var responseErr *vault.ResponseError
+	if errors.As(err, &responseErr) {
+		statusCode = responseErr.StatusCode
+	}
+
+	return &Error{Kind: kind, StatusCode: statusCode, Err: err}
+}
+
+// classify Vault api error by response status code, or as unavailable for a connection failure
+func classifyResponse(err error) error {
+	// step cancellation is not classified
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(err, vault.ErrSecretNotFound) {
+		return NewError(ErrNotFound, err)
+	}
+
+	var responseErr *vault.ResponseError
+	if !errors.As(err, &responseErr) {
+		// connection failure
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return NewError(ErrUnavailable, err)
+		}
+
+		return err
+	}
+
+	switch code := responseErr.StatusCode; {
+	case code == 400:
+		return NewError(ErrInvalidConfig, err)
+	case code == 401:
+		return NewError(ErrAuthFailure, err)
+	case code == 403:
+		return NewError(ErrPermissionDenied, err)
+	case code == 404:
+		return NewError(ErrNotFound, err)
+	case code == 503 && slices.ContainsFunc(responseErr.Errors, func(message string) bool { return strings.Contains(message, "sealed") }):
+		return NewError(ErrSealed, err)
+	case code >= 500:
+		return NewError(ErrUnavailable, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,59 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// test classified error constructor
+func TestNewError(test *testing.T) {
+	if NewError(ErrNotFound, nil) != nil {
+		test.Error("classifying a nil error did not return nil")
+	}
+
+	responseErr := &vault.ResponseError{StatusCode: 403, Errors: []string{"permission denied"}}
+	err := NewError(ErrPermissionDenied, responseErr)
+	var classifiedErr *Error
+	if !errors.As(err, &classifiedErr) || classifiedErr.StatusCode != 403 || classifiedErr.Kind != ErrPermissionDenied {
+		test.Errorf("expected permission denied error with status code 403, actual: %#v", err)
+	}
+	if err.Error() != responseErr.Error() {
+		test.Errorf("expected error message: %s, actual: %s", responseErr.Error(), err.Error())
+	}
+	var unwrappedErr *vault.ResponseError
+	if !errors.Is(err, ErrPermissionDenied) || !errors.As(err, &unwrappedErr) {
+		test.Error("the classified error did not wrap the kind and the vault response error")
+	}
+
+	// existing classification is retained
+	if err = NewError(ErrInvalidConfig, err); errors.Is(err, ErrInvalidConfig) {
+		test.Error("an existing error classification was not retained")
+	}
+}
+
+// test vault api error classification
+func TestClassifyResponse(test *testing.T) {
+	for kind, err := range map[error]error{
+		ErrInvalidConfig:    &vault.ResponseError{StatusCode: 400},
+		ErrAuthFailure:      &vault.ResponseError{StatusCode: 401},
+		ErrPermissionDenied: &vault.ResponseError{StatusCode: 403},
+		ErrNotFound:         vault.ErrSecretNotFound,
+		ErrSealed:           &vault.ResponseError{StatusCode: 503, Errors: []string{"Vault is sealed"}},
+		ErrUnavailable:      &url.Error{Op: "Get", URL: "http://127.0.0.1:8200", Err: errors.New("connection refused")},
+	} {
+		if classifiedErr := classifyResponse(err); !errors.Is(classifiedErr, kind) {
+			test.Errorf("expected error kind: %s, actual: %v", kind, classifiedErr)
+		}
+	}
+
+	// unclassified errors are returned unchanged
+	for _, err := range []error{nil, context.Canceled, errors.New("unclassified"), &vault.ResponseError{StatusCode: 412}} {
+		if classifiedErr := classifyResponse(err); classifiedErr != err {
+			test.Errorf("expected unclassified error: %v, actual: %v", err, classifiedErr)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// server-side generated secret value parameters
+type generateParams struct {
+	Length int    `json:"length"`
+	Format string `json:"format"`
+	Policy string `json:"policy"`
+}
+
+// resolves generate value forms in secretValue to values generated server-side by Vault
+func GenerateSecretValue(ctx context.Context, client *vault.Client, secretValue map[string]any) (map[string]any, error) {
+	resolvedValue := make(map[string]any, len(secretValue))
+
+	for key, value := range secretValue {
+		params, generate, err := parseGenerateForm(key, value)
+		if err != nil {
+			return nil, err
+		}
+		// literal values are assigned as-is
+		if !generate {
+			resolvedValue[key] = value
+			continue
+		}
+
+		// generate from password policy or random bytes
+		if len(params.Policy) > 0 {
+			resolvedValue[key], err = generatePassword(ctx, client, params.Policy)
+		} else {
+			resolvedValue[key], err = generateRandom(ctx, client, params.Length, params.Format)
+		}
+		if err != nil {
+			slog.Error("unable to generate a value", "key", key, "error", err)
+			return nil, err
+		}
+	}
+
+	return resolvedValue, nil
+}
+
+// validates generate value forms in secretValue without generating values (e.g. for a dry run), and returns the keys of the values that would be generated in sorted order
+func GeneratedKeys(secretValue map[string]any) ([]string, error) {
+	generatedKeys := []string{}
+	for _, key := range slices.Sorted(maps.Keys(secretValue)) {
+		_, generate, err := parseGenerateForm(key, secretValue[key])
+		if err != nil {
+			return nil, err
+		}
+		if generate {
+			generatedKeys = append(generatedKeys, key)
+		}
+	}
+
+	return generatedKeys, nil
+}
+
+// parse and validate the generate parameters of a generate value form, and return whether the value is a generate value form (otherwise literal)
+func parseGenerateForm(key string, value any) (generateParams, bool, error) {
+	valueForm, ok := value.(map[string]any)
+	if !ok || valueForm["generate"] == nil {
+		return generateParams{}, false, nil
+	}
+	// the generate value form must not contain other fields (e.g. a typo) that would otherwise be written literally
+	if len(valueForm) != 1 {
+		slog.Error("the generate value form may only contain generate", "key", key)
+		return generateParams{}, false, NewError(ErrInvalidConfig, errors.New("invalid generate value form"))
+	}
+
+	// re-decode the generate parameters strictly to validate their schema
+	paramsJSON, err := json.Marshal(valueForm["generate"])
+	if err != nil {
+		slog.Error("unable to marshal the generate parameters", "key", key, "error", err)
+		return generateParams{}, false, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(paramsJSON))
+	decoder.DisallowUnknownFields()
+	var params generateParams
+	if err = decoder.Decode(&params); err != nil {
+		slog.Error("the generate parameters may only contain length, format, or policy", "key", key, "error", err)
+		return generateParams{}, false, NewError(ErrInvalidConfig, err)
+	}
+	if len(params.Policy) > 0 && (params.Length > 0 || len(params.Format) > 0) {
+		slog.Error("the generate policy parameter is mutually exclusive with length and format", "key", key)
+		return generateParams{}, false, NewError(ErrInvalidConfig, errors.New("generate policy with length or format"))
+	}
+
+	return params, true, nil
+}
+
+// generate random bytes with the vault random tool
+func generateRandom(ctx context.Context, client *vault.Client, length int, format string) (string, error) {
+	// default and validate parameters
+	if length == 0 {
+		length = 32
+	} else if length < 0 {
+		slog.Error("the generate length must be a positive number of bytes", "length", length)
+		return "", NewError(ErrInvalidConfig, errors.New("invalid generate length"))
+	}
+	if len(format) == 0 {
+		format = "base64"
+	} else if !slices.Contains([]string{"base64", "hex"}, format) {
+		slog.Error("the generate format must be either base64 or hex", "format", format)
+		return "", NewError(ErrInvalidConfig, errors.New("invalid generate format"))
+	}
+
+	// generate random bytes
+	rawSecret, err := client.Logical().WriteWithContext(ctx, "sys/tools/random", map[string]any{"bytes": length, "format": format})
+	if err != nil {
+		slog.Error("failed to generate random bytes with the Vault random tool", "error", err)
+		return "", classifyResponse(err)
+	}
+
+	// validate and return random bytes
+	if rawSecret == nil {
+		return "", errors.New("random bytes not generated")
+	}
+	randomBytes, ok := rawSecret.Data["random_bytes"].(string)
+	if !ok {
+		return "", errors.New("random bytes not generated")
+	}
+
+	return randomBytes, nil
+}
+
+// generate password from vault password policy
+func generatePassword(ctx context.Context, client *vault.Client, policy string) (string, error) {
+	rawSecret, err := client.Logical().ReadWithContext(ctx, "sys/policies/password/"+policy+"/generate")
+	if err != nil {
+		slog.Error("failed to generate a password from the Vault password policy", "policy", policy, "error", err)
+		return "", classifyResponse(err)
+	}
+
+	// validate and return password
+	if rawSecret == nil {
+		return "", errors.New("password not generated")
+	}
+	password, ok := rawSecret.Data["password"].(string)
+	if !ok {
+		return "", errors.New("password not generated")
+	}
+
+	return password, nil
+}
@@ -0,0 +1,80 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+// test server-side generated secret values
+func TestGenerateSecretValue(test *testing.T) {
+	secretValue, err := GenerateSecretValue(context.Background(), util.VaultClient, map[string]any{
+		"literal":  "value",
+		"random":   map[string]any{"generate": map[string]any{"length": 16}},
+		"password": map[string]any{"generate": map[string]any{"policy": util.PasswordPolicy}},
+	})
+	if err != nil {
+		test.Error("secret value failed to generate")
+		test.Error(err)
+	}
+	if secretValue["literal"] != "value" {
+		test.Errorf("expected literal value to be unmodified, actual: %v", secretValue["literal"])
+	}
+	if randomBytes, _ := base64.StdEncoding.DecodeString(secretValue["random"].(string)); len(randomBytes) != 16 {
+		test.Errorf("expected 16 random bytes, actual: %v", secretValue["random"])
+	}
+	if password := secretValue["password"].(string); len(password) != 20 {
+		test.Errorf("expected password of length 20 from policy, actual: %s", password)
+	}
+
+	// test errors
+	if _, err = GenerateSecretValue(context.Background(), util.VaultClient, map[string]any{"key": map[string]any{"generate": map[string]any{"policy": util.PasswordPolicy, "length": 8}}}); err == nil || err.Error() != "generate policy with length or format" {
+		test.Errorf("expected error: generate policy with length or format, actual: %v", err)
+	}
+	if _, err = GenerateSecretValue(context.Background(), util.VaultClient, map[string]any{"key": map[string]any{"generate": map[string]any{"foo": "bar"}}}); err == nil {
+		test.Error("expected error for generate parameters with unknown field")
+	}
+	if _, err = GenerateSecretValue(context.Background(), util.VaultClient, map[string]any{"key": map[string]any{"generate": map[string]any{"length": 8}, "typo": 1}}); !errors.Is(err, ErrInvalidConfig) || err.Error() != "invalid generate value form" {
+		test.Errorf("expected error: invalid generate value form, actual: %v", err)
+	}
+}
+
+// test generated keys without generation
+func TestGeneratedKeys(test *testing.T) {
+	generatedKeys, err := GeneratedKeys(map[string]any{
+		"literal":  "value",
+		"random":   map[string]any{"generate": map[string]any{"length": 8}},
+		"password": map[string]any{"generate": map[string]any{"policy": "nonexistent"}},
+	})
+	if err != nil || !slices.Equal(generatedKeys, []string{"password", "random"}) {
+		test.Errorf("expected generated keys: [password random], actual: %v, error: %v", generatedKeys, err)
+	}
+
+	if _, err = GeneratedKeys(map[string]any{"key": map[string]any{"generate": map[string]any{"policy": util.PasswordPolicy, "format": "hex"}}}); err == nil || err.Error() != "generate policy with length or format" {
+		test.Errorf("expected error: generate policy with length or format, actual: %v", err)
+	}
+}
+
+// test random bytes generation
+func TestGenerateRandom(test *testing.T) {
+	randomBytes, err := generateRandom(context.Background(), util.VaultClient, 0, "hex")
+	if err != nil {
+		test.Error("random bytes failed to generate")
+		test.Error(err)
+	}
+	if len(randomBytes) != 64 {
+		test.Errorf("expected default 32 random bytes hex encoded, actual: %s", randomBytes)
+	}
+
+	// test errors
+	if _, err = generateRandom(context.Background(), util.VaultClient, -1, ""); err == nil || err.Error() != "invalid generate length" {
+		test.Errorf("expected error: invalid generate length, actual: %v", err)
+	}
+	if _, err = generateRandom(context.Background(), util.VaultClient, 8, "ascii"); err == nil || err.Error() != "invalid generate format" {
+		test.Errorf("expected error: invalid generate format, actual: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package vault
+
+import (
+	"context"
+	"log/slog"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// revoke dynamic secret lease immediately
+func RevokeLease(ctx context.Context, client *vault.Client, leaseId string) error {
+	if err := client.Sys().RevokeWithContext(ctx, leaseId); err != nil {
+		slog.Error("the secret lease could not be revoked", "lease_id", leaseId, "error", err)
+		return classifyResponse(err)
+	}
+
+	return nil
+}
+
+// revoke all dynamic secret leases with the lease ID prefix immediately (requires sudo capability on the prefix)
+func RevokeLeasePrefix(ctx context.Context, client *vault.Client, prefix string) error {
+	if err := client.Sys().RevokePrefixWithContext(ctx, prefix); err != nil {
+		slog.Error("the secret leases with lease ID prefix could not be revoked", "prefix", prefix, "error", err)
+		return classifyResponse(err)
+	}
+
+	return nil
+}
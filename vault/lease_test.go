@@ -0,0 +1,57 @@
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+// test lease revocation
+func TestRevokeLease(test *testing.T) {
+	// credentials generation requires a configured role
+	if util.Fake == nil {
+		test.Skip("credentials generation requires the fake vault server")
+	}
+
+	dbEngine := credentialEngine{engine: enum.Database}
+	leaseIds := []string{}
+	for range 3 {
+		_, secretMetadata, err := dbEngine.Read(context.Background(), util.VaultClient, "database", util.FakeCredentialRole, "")
+		if err != nil {
+			test.Fatal(err)
+		}
+		leaseIds = append(leaseIds, secretMetadata.LeaseID)
+	}
+
+	// revoke single lease
+	if err := RevokeLease(context.Background(), util.VaultClient, leaseIds[0]); err != nil {
+		test.Error("lease failed to revoke")
+		test.Error(err)
+	}
+	if _, err := util.VaultClient.Sys().Renew(leaseIds[0], 0); err == nil {
+		test.Errorf("revoked lease %s was renewable", leaseIds[0])
+	}
+	if _, err := util.VaultClient.Sys().Renew(leaseIds[1], 0); err != nil {
+		test.Errorf("unrevoked lease %s was not renewable: %s", leaseIds[1], err)
+	}
+
+	// revoke remaining leases by prefix
+	if err := RevokeLeasePrefix(context.Background(), util.VaultClient, "database/creds/"+util.FakeCredentialRole); err != nil {
+		test.Error("lease prefix failed to revoke")
+		test.Error(err)
+	}
+	for _, leaseId := range leaseIds[1:] {
+		if _, err := util.VaultClient.Sys().Renew(leaseId, 0); err == nil {
+			test.Errorf("revoked lease %s was renewable", leaseId)
+		}
+	}
+
+	// test errors
+	util.VaultClient.SetToken("invalid")
+	defer util.VaultClient.SetToken(util.VaultToken)
+	if err := RevokeLease(context.Background(), util.VaultClient, leaseIds[0]); err == nil {
+		test.Error("lease revocation with invalid token did not error")
+	}
+}
@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+)
+
+// list secret paths recursively beneath the path prefix for the secret engines that support listing
+func ListSecretPaths(ctx context.Context, client *vault.Client, engine enum.SecretEngine, mount string, prefix string) ([]string, error) {
+	// prefix is a directory so ensure trailing separator unless mount root
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	// determine logical path for listing
+	secretEngine, err := lookupSecretEngine(engine)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := secretEngine.(Lister)
+	if !ok {
+		slog.Error("secrets can only be listed for secret engines that support listing (e.g. kv1 and kv2)", "engine", engine)
+		return nil, NewError(ErrInvalidConfig, errors.New("secret listing unsupported"))
+	}
+	listPath := lister.ListPath(mount, prefix)
+
+	rawSecret, err := client.Logical().ListWithContext(ctx, listPath)
+	if err != nil {
+		slog.Error("failed to list secrets at prefix", "engine", engine, "mount", mount, "prefix", prefix, "error", err)
+		return nil, classifyResponse(err)
+	}
+	if rawSecret == nil {
+		slog.Error("no secrets exist at prefix", "engine", engine, "mount", mount, "prefix", prefix)
+		return nil, NewError(ErrNotFound, errors.New("no secrets at prefix"))
+	}
+	keys, ok := rawSecret.Data["keys"].([]any)
+	if !ok {
+		slog.Error("the list response did not contain keys", "engine", engine, "mount", mount, "prefix", prefix)
+		return nil, errors.New("invalid list response")
+	}
+
+	// keys with trailing separator are nested prefixes
+	paths := []string{}
+	for _, key := range keys {
+		keyString, _ := key.(string)
+		if !strings.HasSuffix(keyString, "/") {
+			paths = append(paths, prefix+keyString)
+			continue
+		}
+
+		nestedPaths, err := ListSecretPaths(ctx, client, engine, mount, prefix+keyString)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, nestedPaths...)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,35 @@
+package vault
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/mschuchard/concourse-vault-resource/enum"
+	"github.com/mschuchard/concourse-vault-resource/vault/util"
+)
+
+// test secret paths listing
+func TestListSecretPaths(test *testing.T) {
+	// recursive listing from mount root
+	paths, err := ListSecretPaths(context.Background(), util.VaultClient, enum.KeyValue2, util.KV2Mount, "")
+	if err != nil || !slices.Contains(paths, util.KVPath) || !slices.Contains(paths, "bar/baz") {
+		test.Errorf("expected kv2 paths to contain %s and bar/baz, actual: %v, error: %v", util.KVPath, paths, err)
+	}
+
+	// listing beneath prefix with and without trailing separator
+	for _, prefix := range []string{"foo", "foo/"} {
+		paths, err = ListSecretPaths(context.Background(), util.VaultClient, enum.KeyValue1, util.KV1Mount, prefix)
+		if err != nil || !slices.Contains(paths, util.KVPath) {
+			test.Errorf("expected kv1 paths beneath prefix %s to contain %s, actual: %v, error: %v", prefix, util.KVPath, paths, err)
+		}
+	}
+
+	// test errors
+	if _, err = ListSecretPaths(context.Background(), util.VaultClient, enum.KeyValue2, util.KV2Mount, "nonexistent"); err == nil || err.Error() != "no secrets at prefix" {
+		test.Errorf("expected error: no secrets at prefix, actual: %v", err)
+	}
+	if _, err = ListSecretPaths(context.Background(), util.VaultClient, enum.Generic, "sys", "mounts"); err == nil || err.Error() != "secret listing unsupported" {
+		test.Errorf("expected error: secret listing unsupported, actual: %v", err)
+	}
+}
@@ -1,129 +1,104 @@
 package vault
 
 import (
+	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
 	vault "github.com/hashicorp/vault/api"
 	"github.com/mschuchard/concourse-vault-resource/enum"
+	"github.com/mschuchard/concourse-vault-resource/redact"
 )
 
 // secret defines a composite Vault secret configuration
 type vaultSecret struct {
-	engine  enum.SecretEngine
-	mount   string
-	path    string
-	dynamic bool
+	engine       enum.SecretEngine
+	mount        string
+	path         string
+	secretEngine SecretEngine
 }
 
 // secret constructor
 func NewVaultSecret(engine enum.SecretEngine, mount string, path string) (*vaultSecret, error) {
 	// validate mandatory fields specified
 	if len(engine) == 0 || len(path) == 0 {
-		log.Print("the secret engine and path parameters are mandatory")
-		return nil, errors.New("required param(s) missing")
+		slog.Error("the secret engine and path parameters are mandatory", "engine", engine, "path", path)
+		return nil, NewError(ErrInvalidConfig, errors.New("required param(s) missing"))
 	}
 
-	// validate engine parameter
-	_, err := engine.New()
+	// validate engine parameter is registered
+	secretEngine, err := lookupSecretEngine(engine)
 	if err != nil {
 		return nil, err
 	}
 
-	// initialize vault secret
-	vaultSecret := &vaultSecret{
-		engine: engine,
-		path:   path,
-		mount:  mount,
+	// default mount point
+	if len(mount) == 0 {
+		mount = secretEngine.DefaultMount()
 	}
 
-	// determine if secret is dynamic and default mount point
-	// note current enum renders default mount setting pointless, but it would ensure safety to retain
-	switch engine {
-	case enum.KeyValue1:
-		vaultSecret.dynamic = false
-
-		if len(mount) == 0 {
-			vaultSecret.mount = "kv"
-		}
-	case enum.KeyValue2:
-		vaultSecret.dynamic = false
-
-		if len(mount) == 0 {
-			vaultSecret.mount = "secret"
-		}
-	case enum.Database, enum.AWS, enum.Azure, enum.Consul, enum.Kubernetes, enum.Nomad, enum.RabbitMQ, enum.SSH, enum.Terraform:
-		vaultSecret.dynamic = true
-
-		if len(mount) == 0 {
-			vaultSecret.mount = string(engine)
-		}
-	default:
-		log.Printf("an invalid secret engine %s was selected", engine)
-		return nil, errors.New("invalid secret engine")
-	}
-
-	return vaultSecret, nil
+	// return initialized vault secret
+	return &vaultSecret{
+		engine:       engine,
+		mount:        mount,
+		path:         path,
+		secretEngine: secretEngine,
+	}, nil
 }
 
-// secret readers
-func (secret *vaultSecret) Dynamic() bool {
-	return secret.dynamic
+// return secret value, version, metadata, and possible error (GET/READ/READ)
+func (secret *vaultSecret) SecretValue(ctx context.Context, client *vault.Client, version string) (map[string]any, Metadata, error) {
+	defer secret.logOperation("read", time.Now())
+
+	secretValue, metadata, err := secret.secretEngine.Read(ctx, client, secret.mount, secret.path, version)
+	// mask retrieved secret values in log output
+	redact.RegisterSecretValue(secretValue)
+
+	return secretValue, metadata, err
 }
 
-// return secret value, version, metadata, and possible error (GET/READ/READ)
-func (secret *vaultSecret) SecretValue(client *vault.Client, version string) (map[string]any, Metadata, error) {
-	if secret.dynamic {
-		return secret.generateCredentials(client)
-	} else {
-		return secret.retrieveKVSecret(client, version)
-	}
+// populate secret and return version, metadata, and error (POST/WRITE/CREATE+PUT/PATCH/UPDATE)
+func (secret *vaultSecret) PopulateSecret(ctx context.Context, client *vault.Client, secretValue map[string]any, patch bool) (Metadata, error) {
+	// mask written secret values in log output including errors echoing the request body
+	redact.RegisterSecretValue(secretValue)
+	defer secret.logOperation("write", time.Now())
+
+	return secret.secretEngine.Write(ctx, client, secret.mount, secret.path, secretValue, patch)
 }
 
-// populate key-value pair secrets and return version, metadata, and error (POST/WRITE/CREATE+PUT/PATCH/UPDATE)
-func (secret *vaultSecret) PopulateKVSecret(client *vault.Client, secretValue map[string]any, patch bool) (Metadata, error) {
-	switch secret.engine {
-	case enum.KeyValue1:
-		return secret.populateKV1Secret(client, secretValue)
-	case enum.KeyValue2:
-		return secret.populateKV2Secret(client, secretValue, patch)
-	default:
-		log.Printf("an invalid secret engine %s was selected", secret.engine)
-		return Metadata{}, errors.New("invalid secret engine")
+// compare secret value to the current secret value (empty if the secret does not exist) without writing, and return the key diff and current metadata
+func (secret *vaultSecret) DiffSecret(ctx context.Context, client *vault.Client, secretValue map[string]any, patch bool) (SecretValueDiff, Metadata, error) {
+	if err := ValidateDiff(secret.engine); err != nil {
+		return SecretValueDiff{}, Metadata{}, err
 	}
+	// mask compared secret values in log output
+	redact.RegisterSecretValue(secretValue)
+	defer secret.logOperation("diff", time.Now())
+
+	return secret.secretEngine.(Differ).Diff(ctx, client, secret.mount, secret.path, secretValue, patch)
 }
 
 // renew dynamic secret lease and return updated metadata
-func (secret *vaultSecret) Renew(client *vault.Client, leaseIdSuffix string) (Metadata, error) {
-	// semi-validate secret is renewable (better but not possible is *Secret.Renewable)
-	if !secret.dynamic {
-		log.Printf("the input secret with engine %s at mount %s and path %s is not renewable", secret.engine, secret.mount, secret.path)
-		return Metadata{}, errors.New("non-renewable secret")
-	}
+func (secret *vaultSecret) Renew(ctx context.Context, client *vault.Client, lease Lease) (Metadata, error) {
+	defer secret.logOperation("renew", time.Now())
 
-	// determine full lease id
-	leaseId := secret.mount + "/creds/" + secret.path + "/" + leaseIdSuffix
+	return secret.secretEngine.Renew(ctx, client, secret.mount, secret.path, lease)
+}
 
-	// renew the secret lease
-	rawSecret, err := client.Sys().Renew(leaseId, 0)
-	if err != nil {
-		log.Printf("the secret with lease ID %s could not be renewed", leaseId)
-		return Metadata{}, err
-	}
-	log.Printf("the lease for %s was successfully renewed", leaseId)
+// return current version of secret
+func (secret *vaultSecret) Version(ctx context.Context, client *vault.Client) (string, error) {
+	return secret.secretEngine.Version(ctx, client, secret.mount, secret.path)
+}
 
-	// initialize secret metadata and assign version
-	metadata, err := rawSecretToMetadata(rawSecret)
-	if err != nil {
-		log.Print("raw secret could not be converted to metadata")
-		return Metadata{}, err
-	}
+// return versions of secret from input version through current version
+func (secret *vaultSecret) Check(ctx context.Context, client *vault.Client, version string, options CheckOptions) ([]SecretVersion, error) {
+	defer secret.logOperation("check", time.Now())
 
-	// calculate the expiration time for version and assign to metadata
-	expirationTime := time.Now().Local().Add(metadata.LeaseDuration)
-	metadata.Version = expirationTime.Format("2006-01-02-150405")
+	return secret.secretEngine.Check(ctx, client, secret.mount, secret.path, version, options)
+}
 
-	// convert raw secret to metadata and return metadata and version
-	return metadata, nil
+// log the secret operation with its duration since start
+func (secret *vaultSecret) logOperation(operation string, start time.Time) {
+	slog.Debug("secret operation completed", "operation", operation, "engine", secret.engine, "mount", secret.mount, "path", secret.path, "duration", time.Since(start))
 }
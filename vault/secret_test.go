@@ -1,9 +1,14 @@
 package vault
 
 import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/mschuchard/concourse-vault-resource/enum"
+	"github.com/mschuchard/concourse-vault-resource/redact"
 	"github.com/mschuchard/concourse-vault-resource/vault/util"
 )
 
@@ -15,10 +20,10 @@ func TestNewVaultSecret(test *testing.T) {
 		test.Error(err)
 	}
 	expectedVaultSecret := vaultSecret{
-		engine:  enum.Database,
-		mount:   "database",
-		path:    util.KVPath,
-		dynamic: true,
+		engine:       enum.Database,
+		mount:        "database",
+		path:         util.KVPath,
+		secretEngine: credentialEngine{engine: enum.Database, endpoint: "static-creds"},
 	}
 
 	if *dbVaultSecret != expectedVaultSecret {
@@ -33,10 +38,10 @@ func TestNewVaultSecret(test *testing.T) {
 		test.Error(err)
 	}
 	expectedVaultSecret = vaultSecret{
-		engine:  enum.AWS,
-		mount:   "gcp",
-		path:    util.KVPath,
-		dynamic: true,
+		engine:       enum.AWS,
+		mount:        "gcp",
+		path:         util.KVPath,
+		secretEngine: credentialEngine{engine: enum.AWS, endpoint: "sts"},
 	}
 
 	if *awsVaultSecret != expectedVaultSecret {
@@ -45,22 +50,106 @@ func TestNewVaultSecret(test *testing.T) {
 		test.Errorf("actual values: %v", *awsVaultSecret)
 	}
 
+	genericVaultSecret, err := NewVaultSecret("generic", "", "sys/mounts")
+	if err != nil {
+		test.Error("generic secret failed to construct")
+		test.Error(err)
+	}
+	expectedVaultSecret = vaultSecret{
+		engine:       enum.Generic,
+		mount:        "",
+		path:         "sys/mounts",
+		secretEngine: genericEngine{},
+	}
+
+	if *genericVaultSecret != expectedVaultSecret {
+		test.Error("the generic vault secret constructor returned unexpected values")
+		test.Errorf("expected values: %v", expectedVaultSecret)
+		test.Errorf("actual values: %v", *genericVaultSecret)
+	}
+
 	if _, err = NewVaultSecret("", "", ""); err == nil || err.Error() != "required param(s) missing" {
 		test.Error("constructor did not return expected error for missing parameters")
 		test.Errorf("expected: required param(s) missing, actual: %s", err)
 	}
 
-	if _, err = NewVaultSecret("foo", "bar", "baz"); err == nil || err.Error() != "invalid secretengine enum" {
+	if _, err = NewVaultSecret("foo", "bar", "baz"); err == nil || err.Error() != "invalid secret engine" {
 		test.Error("constructor did not return expected error for invalid secrets engine")
-		test.Errorf("expected: invalid secretengine enum, actual: %s", err)
+		test.Errorf("expected: invalid secret engine, actual: %s", err)
 	}
 }
 
 // test secret renew
 func TestRenew(test *testing.T) {
-	staticSecret := vaultSecret{dynamic: false}
-	if _, err := staticSecret.Renew(util.VaultClient, ""); err == nil || err.Error() != "non-renewable secret" {
+	staticSecret := vaultSecret{secretEngine: kv2Engine{}}
+	if _, err := staticSecret.Renew(context.Background(), util.VaultClient, Lease{}); err == nil || err.Error() != "non-renewable secret" {
 		test.Error("renew did not return expected error for non-dynamic secret")
 		test.Errorf("expected: non-renewable secret, actual: %s", err)
 	}
 }
+
+// test secret comparison to current secret without writing
+func TestDiffSecret(test *testing.T) {
+	if _, err := util.VaultClient.KVv2(util.KV2Mount).Put(context.Background(), "diff", map[string]any{"kept": "value", "number": 1}); err != nil {
+		test.Fatal(err)
+	}
+	kvSecret, err := NewVaultSecret(enum.KeyValue2, util.KV2Mount, "diff")
+	if err != nil {
+		test.Fatal(err)
+	}
+
+	diff, metadata, err := kvSecret.DiffSecret(context.Background(), util.VaultClient, map[string]any{"number": 2, "added": "value"}, false)
+	expectedDiff := SecretValueDiff{Added: []string{"added"}, Changed: []string{"number"}, Removed: []string{"kept"}}
+	if err != nil || !reflect.DeepEqual(diff, expectedDiff) || metadata.Version != "1" {
+		test.Errorf("expected diff: %v, and version: 1, actual: %v, version: %s, error: %v", expectedDiff, diff, metadata.Version, err)
+	}
+
+	// patch merges into current secret value
+	diff, _, err = kvSecret.DiffSecret(context.Background(), util.VaultClient, map[string]any{"number": 1, "kept": nil}, true)
+	expectedDiff = SecretValueDiff{Added: []string{}, Changed: []string{}, Removed: []string{"kept"}}
+	if err != nil || !reflect.DeepEqual(diff, expectedDiff) {
+		test.Errorf("expected patch diff: %v, actual: %v, error: %v", expectedDiff, diff, err)
+	}
+
+	// nothing was written
+	if kv2Secret, err := util.VaultClient.KVv2(util.KV2Mount).Get(context.Background(), "diff"); err != nil || kv2Secret.VersionMetadata.Version != 1 {
+		test.Errorf("diff wrote the secret: %v, error: %v", kv2Secret, err)
+	}
+
+	// nonexistent secret is empty
+	kvSecret, _ = NewVaultSecret(enum.KeyValue1, util.KV1Mount, "diff/nonexistent")
+	if diff, metadata, err = kvSecret.DiffSecret(context.Background(), util.VaultClient, map[string]any{"added": "value"}, false); err != nil || !slices.Equal(diff.Added, []string{"added"}) || len(metadata.Version) > 0 {
+		test.Errorf("expected added keys for nonexistent secret, actual: %v, version: %s, error: %v", diff, metadata.Version, err)
+	}
+
+	// unsupported engine
+	genericSecret, _ := NewVaultSecret(enum.Generic, "sys", "policy/default")
+	if _, _, err = genericSecret.DiffSecret(context.Background(), util.VaultClient, map[string]any{}, false); !errors.Is(err, ErrInvalidConfig) {
+		test.Errorf("expected invalid config error for generic engine diff, actual: %v", err)
+	}
+}
+
+// test secret values are masked in log output
+func TestSecretValueRedaction(test *testing.T) {
+	kvSecret, err := NewVaultSecret(enum.KeyValue2, util.KV2Mount, util.KVPath)
+	if err != nil {
+		test.Fatal(err)
+	}
+	if _, _, err = kvSecret.SecretValue(context.Background(), util.VaultClient, ""); err != nil {
+		test.Fatal(err)
+	}
+	if masked := redact.String("retrieved " + util.KVValue); masked != "retrieved "+redact.Mask {
+		test.Errorf("retrieved secret value was not masked: %s", masked)
+	}
+
+	writeSecret, err := NewVaultSecret(enum.KeyValue2, util.KV2Mount, "redaction")
+	if err != nil {
+		test.Fatal(err)
+	}
+	if _, err = writeSecret.PopulateSecret(context.Background(), util.VaultClient, map[string]any{util.KVKey: "writtensecret"}, false); err != nil {
+		test.Fatal(err)
+	}
+	if masked := redact.String("written writtensecret"); masked != "written "+redact.Mask {
+		test.Errorf("written secret value was not masked: %s", masked)
+	}
+}
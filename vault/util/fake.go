@@ -0,0 +1,504 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// in-process fake of the Vault HTTP API endpoints utilized by this resource
+type FakeVault struct {
+	*httptest.Server
+	mutex     sync.Mutex
+	tokens    map[string]bool
+	roleIDs   map[string]string // key is approle role name, and value is role id
+	secretIDs map[string]string // key is approle secret id, and value is role id
+	policies  map[string]int    // key is password policy name, and value is password length
+	kv1       map[string]map[string]any
+	kv2       map[string][]fakeKV2Version // index is version - 1
+	logical   map[string]map[string]any
+	leases    map[string]int // key is lease id, and value is lease duration
+	// server health status
+	sealed             bool
+	standby            bool
+	performanceStandby bool
+	// number of subsequent requests that will fail transiently, and their response status
+	failures      int
+	failureStatus int
+}
+
+// single version of a kv2 secret
+type fakeKV2Version struct {
+	data         map[string]any
+	createdTime  time.Time
+	deletionTime time.Time
+	destroyed    bool
+}
+
+// secrets engine mount types
+var (
+	fakeKV1Mounts        = []string{KV1Mount}
+	fakeKV2Mounts        = []string{KV2Mount}
+	fakeCredentialMounts = []string{"aws", "database", "ssh"}
+)
+
+// creation time of the seeded kv2 secrets for deterministic version output
+var fakeSeedTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// the only role configured for credential generation
+const FakeCredentialRole = "readonly"
+
+// fake vault constructor seeded with the same data as TestBootstrap
+func NewFakeVault() *FakeVault {
+	fake := &FakeVault{
+		tokens:    map[string]bool{VaultToken: true},
+		roleIDs:   map[string]string{"myAppRole": fakeUUID()},
+		secretIDs: map[string]string{},
+		policies:  map[string]int{PasswordPolicy: 20},
+		kv1:       map[string]map[string]any{KV1Mount + "/" + KVPath: {KVKey: KVValue}},
+		kv2: map[string][]fakeKV2Version{
+			KV2Mount + "/" + KVPath: {{data: map[string]any{KVKey: KVValue, "other_password": "ultrasecret"}, createdTime: fakeSeedTime}},
+			KV2Mount + "/bar/baz":   {{data: map[string]any{KVKey: KVValue}, createdTime: fakeSeedTime}},
+		},
+		logical: map[string]map[string]any{},
+		leases:  map[string]int{},
+	}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.handle))
+
+	return fake
+}
+
+// fail the next count requests with the response status (e.g. 503 for standby failover or 429 for rate limiting)
+func (fake *FakeVault) FailRequests(count int, status int) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	fake.failures = count
+	fake.failureStatus = status
+}
+
+// return the lease ids of the issued dynamic credentials in sorted order
+func (fake *FakeVault) LeaseIDs() []string {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	return slices.Sorted(maps.Keys(fake.leases))
+}
+
+// set server health status for seal status and health endpoints
+func (fake *FakeVault) SetHealth(sealed bool, standby bool, performanceStandby bool) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	fake.sealed = sealed
+	fake.standby = standby
+	fake.performanceStandby = performanceStandby
+}
+
+// route requests to fake endpoints
+func (fake *FakeVault) handle(writer http.ResponseWriter, request *http.Request) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	// transient failure
+	if fake.failures > 0 {
+		fake.failures--
+		fakeError(writer, fake.failureStatus, "transient failure")
+		return
+	}
+
+	path := strings.TrimPrefix(request.URL.Path, "/v1/")
+	mount, subPath, _ := strings.Cut(path, "/")
+
+	// decode request body if any
+	body := map[string]any{}
+	if request.Body != nil && request.ContentLength != 0 {
+		if err := json.NewDecoder(request.Body).Decode(&body); err != nil {
+			fakeError(writer, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	// unauthenticated endpoints
+	switch path {
+	case "sys/seal-status":
+		fakeJSON(writer, http.StatusOK, map[string]any{"type": "shamir", "initialized": true, "sealed": fake.sealed, "t": 1, "n": 1})
+		return
+	case "sys/health":
+		fakeJSON(writer, http.StatusOK, map[string]any{"initialized": true, "sealed": fake.sealed, "standby": fake.standby, "performance_standby": fake.performanceStandby})
+		return
+	case "auth/approle/login":
+		fake.approleLogin(writer, body)
+		return
+	}
+
+	// validate token for all other endpoints
+	if !fake.tokens[request.Header.Get("X-Vault-Token")] {
+		fakeError(writer, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	switch {
+	case path == "sys/auth":
+		auths := map[string]any{}
+		for _, auth := range []string{"approle", "aws", "kubernetes", "token"} {
+			auths[auth+"/"] = map[string]any{"type": auth}
+		}
+		fakeSecret(writer, auths, "", 0)
+	case path == "sys/tools/random":
+		fake.random(writer, body)
+	case strings.HasPrefix(path, "sys/policies/password/"):
+		fake.passwordPolicy(writer, request.Method, strings.TrimPrefix(path, "sys/policies/password/"), body)
+	case path == "sys/leases/renew":
+		fake.renew(writer, body)
+	case path == "sys/leases/revoke":
+		// revocation of a nonexistent lease succeeds as with vault
+		leaseID, _ := body["lease_id"].(string)
+		delete(fake.leases, leaseID)
+		writer.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "sys/leases/revoke-prefix/"):
+		prefix := strings.TrimPrefix(path, "sys/leases/revoke-prefix/")
+		maps.DeleteFunc(fake.leases, func(leaseID string, _ int) bool { return strings.HasPrefix(leaseID, prefix) })
+		writer.WriteHeader(http.StatusNoContent)
+	case strings.HasPrefix(path, "auth/approle/role/"):
+		fake.approleRole(writer, request.Method, strings.TrimPrefix(path, "auth/approle/role/"))
+	case slices.Contains(fakeKV1Mounts, mount) && request.URL.Query().Get("list") == "true":
+		fakeList(writer, slices.Collect(maps.Keys(fake.kv1)), path)
+	case slices.Contains(fakeKV1Mounts, mount):
+		fake.kv1Secret(writer, request.Method, path, body)
+	case slices.Contains(fakeKV2Mounts, mount):
+		fake.kv2Secret(writer, request, mount, subPath, body)
+	case slices.Contains(fakeCredentialMounts, mount) && (strings.HasPrefix(subPath, "creds/") || strings.HasPrefix(subPath, "sts/")):
+		fake.credentials(writer, path)
+	default:
+		fake.logicalSecret(writer, request.Method, path, body)
+	}
+}
+
+// approle authentication endpoints
+func (fake *FakeVault) approleRole(writer http.ResponseWriter, method string, path string) {
+	role, endpoint, _ := strings.Cut(path, "/")
+
+	switch endpoint {
+	case "":
+		if _, ok := fake.roleIDs[role]; !ok {
+			fake.roleIDs[role] = fakeUUID()
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	case "role-id":
+		roleID, ok := fake.roleIDs[role]
+		if !ok {
+			fakeError(writer, http.StatusNotFound, "role not found")
+			return
+		}
+		fakeSecret(writer, map[string]any{"role_id": roleID}, "", 0)
+	case "secret-id":
+		roleID, ok := fake.roleIDs[role]
+		if !ok || method == http.MethodGet {
+			fakeError(writer, http.StatusNotFound, "role not found")
+			return
+		}
+		secretID := fakeUUID()
+		fake.secretIDs[secretID] = roleID
+		fakeSecret(writer, map[string]any{"secret_id": secretID, "secret_id_accessor": fakeUUID()}, "", 0)
+	default:
+		fakeError(writer, http.StatusNotFound, "unsupported path")
+	}
+}
+
+func (fake *FakeVault) approleLogin(writer http.ResponseWriter, body map[string]any) {
+	roleID, _ := body["role_id"].(string)
+	secretID, _ := body["secret_id"].(string)
+	if len(roleID) == 0 || fake.secretIDs[secretID] != roleID {
+		fakeError(writer, http.StatusBadRequest, "invalid role or secret ID")
+		return
+	}
+
+	token := "hvs." + fakeUUID()
+	fake.tokens[token] = true
+	fakeJSON(writer, http.StatusOK, map[string]any{"auth": map[string]any{"client_token": token, "lease_duration": 3600, "renewable": true}})
+}
+
+// random bytes tool
+func (fake *FakeVault) random(writer http.ResponseWriter, body map[string]any) {
+	length := 32
+	if bytes, ok := body["bytes"].(float64); ok {
+		length = int(bytes)
+	}
+	randomBytes := make([]byte, length)
+	rand.Read(randomBytes)
+
+	if body["format"] == "hex" {
+		fakeSecret(writer, map[string]any{"random_bytes": hex.EncodeToString(randomBytes)}, "", 0)
+	} else {
+		fakeSecret(writer, map[string]any{"random_bytes": base64.StdEncoding.EncodeToString(randomBytes)}, "", 0)
+	}
+}
+
+// password policy creation and generation
+func (fake *FakeVault) passwordPolicy(writer http.ResponseWriter, method string, path string, body map[string]any) {
+	if name, ok := strings.CutSuffix(path, "/generate"); ok {
+		length, ok := fake.policies[name]
+		if !ok {
+			fakeError(writer, http.StatusBadRequest, "policy does not exist")
+			return
+		}
+		// hex encoding is within the charset of the test password policy
+		randomBytes := make([]byte, length)
+		rand.Read(randomBytes)
+		fakeSecret(writer, map[string]any{"password": hex.EncodeToString(randomBytes)[:length]}, "", 0)
+		return
+	}
+
+	if method == http.MethodGet {
+		fakeError(writer, http.StatusNotFound, "unsupported path")
+		return
+	}
+	policy, _ := body["policy"].(string)
+	length := 20
+	if match := regexp.MustCompile(`length\s*=\s*(\d+)`).FindStringSubmatch(policy); match != nil {
+		length, _ = strconv.Atoi(match[1])
+	}
+	fake.policies[path] = length
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// recursive json merge patch (RFC 7386) of the data where null values remove keys
+func mergePatch(data map[string]any, patch map[string]any) map[string]any {
+	patched := maps.Clone(data)
+	if patched == nil {
+		patched = map[string]any{}
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(patched, key)
+		} else if patchObject, ok := value.(map[string]any); ok {
+			dataObject, _ := patched[key].(map[string]any)
+			patched[key] = mergePatch(dataObject, patchObject)
+		} else {
+			patched[key] = value
+		}
+	}
+
+	return patched
+}
+
+// dynamic credentials generation and lease renewal
+func (fake *FakeVault) credentials(writer http.ResponseWriter, path string) {
+	if !strings.HasSuffix(path, "/"+FakeCredentialRole) {
+		fakeError(writer, http.StatusBadRequest, "unknown role")
+		return
+	}
+
+	leaseID := path + "/" + fakeUUID()
+	fake.leases[leaseID] = 3600
+	fakeJSON(writer, http.StatusOK, map[string]any{
+		"lease_id":       leaseID,
+		"lease_duration": 3600,
+		"renewable":      true,
+		"data":           map[string]any{"username": "v-" + fakeUUID()[:8], "password": fakeUUID()},
+	})
+}
+
+func (fake *FakeVault) renew(writer http.ResponseWriter, body map[string]any) {
+	leaseID, _ := body["lease_id"].(string)
+	duration, ok := fake.leases[leaseID]
+	if !ok {
+		fakeError(writer, http.StatusBadRequest, "lease not found or lease is not renewable")
+		return
+	}
+	if increment, ok := body["increment"].(float64); ok && increment > 0 {
+		duration = int(increment)
+	}
+	fakeJSON(writer, http.StatusOK, map[string]any{"lease_id": leaseID, "lease_duration": duration, "renewable": true})
+}
+
+// kv1 secrets engine
+func (fake *FakeVault) kv1Secret(writer http.ResponseWriter, method string, path string, body map[string]any) {
+	switch method {
+	case http.MethodGet:
+		data, ok := fake.kv1[path]
+		if !ok {
+			fakeError(writer, http.StatusNotFound)
+			return
+		}
+		fakeSecret(writer, data, "", 0)
+	case http.MethodPost, http.MethodPut:
+		fake.kv1[path] = body
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		fakeError(writer, http.StatusMethodNotAllowed, "unsupported operation")
+	}
+}
+
+// kv2 secrets engine
+func (fake *FakeVault) kv2Secret(writer http.ResponseWriter, request *http.Request, mount string, subPath string, body map[string]any) {
+	endpoint, secretPath, _ := strings.Cut(subPath, "/")
+	key := mount + "/" + secretPath
+	versions := fake.kv2[key]
+
+	switch {
+	case endpoint == "metadata" && request.URL.Query().Get("list") == "true":
+		fakeList(writer, slices.Collect(maps.Keys(fake.kv2)), key)
+	case endpoint == "metadata" && request.Method == http.MethodGet:
+		if len(versions) == 0 {
+			fakeError(writer, http.StatusNotFound)
+			return
+		}
+		versionsMetadata := map[string]any{}
+		for index, version := range versions {
+			versionsMetadata[strconv.Itoa(index+1)] = version.metadata(index + 1)
+		}
+		fakeSecret(writer, map[string]any{
+			"current_version": len(versions),
+			"oldest_version":  1,
+			"max_versions":    0,
+			"created_time":    versions[0].createdTime.Format(time.RFC3339Nano),
+			"updated_time":    versions[len(versions)-1].createdTime.Format(time.RFC3339Nano),
+			"versions":        versionsMetadata,
+		}, "", 0)
+	case endpoint == "data" && request.Method == http.MethodGet:
+		// determine version to read
+		version := len(versions)
+		if versionParam := request.URL.Query().Get("version"); len(versionParam) > 0 && versionParam != "0" {
+			version, _ = strconv.Atoi(versionParam)
+		}
+		if version < 1 || version > len(versions) || versions[version-1].destroyed || !versions[version-1].deletionTime.IsZero() {
+			fakeError(writer, http.StatusNotFound)
+			return
+		}
+		fakeSecret(writer, map[string]any{"data": versions[version-1].data, "metadata": versions[version-1].metadata(version)}, "", 0)
+	case endpoint == "data" && (request.Method == http.MethodPost || request.Method == http.MethodPut || request.Method == http.MethodPatch):
+		data, _ := body["data"].(map[string]any)
+		if request.Method == http.MethodPatch {
+			if len(versions) == 0 {
+				fakeError(writer, http.StatusNotFound)
+				return
+			}
+			// json merge patch of latest version data
+			data = mergePatch(versions[len(versions)-1].data, data)
+		}
+		fake.kv2[key] = append(versions, fakeKV2Version{data: data, createdTime: time.Now().UTC()})
+		fakeSecret(writer, fake.kv2[key][len(versions)].metadata(len(versions)+1), "", 0)
+	case endpoint == "delete" || endpoint == "destroy":
+		// versions are strings for delete and integers for destroy
+		versionsParam, _ := body["versions"].([]any)
+		for _, versionParam := range versionsParam {
+			version, _ := strconv.Atoi(fmt.Sprint(versionParam))
+			if version < 1 || version > len(versions) {
+				continue
+			}
+			if endpoint == "delete" {
+				versions[version-1].deletionTime = time.Now().UTC()
+			} else {
+				versions[version-1].destroyed = true
+			}
+		}
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		fakeError(writer, http.StatusMethodNotAllowed, "unsupported operation")
+	}
+}
+
+// kv2 version metadata as returned by the api
+func (version fakeKV2Version) metadata(number int) map[string]any {
+	deletionTime := ""
+	if !version.deletionTime.IsZero() {
+		deletionTime = version.deletionTime.Format(time.RFC3339Nano)
+	}
+
+	return map[string]any{
+		"version":       number,
+		"created_time":  version.createdTime.Format(time.RFC3339Nano),
+		"deletion_time": deletionTime,
+		"destroyed":     version.destroyed,
+	}
+}
+
+// list keys directly beneath the prefix with trailing separator for nested prefixes
+func fakeList(writer http.ResponseWriter, secretPaths []string, prefix string) {
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	keys := []string{}
+	for _, secretPath := range secretPaths {
+		remainder, ok := strings.CutPrefix(secretPath, prefix)
+		if !ok {
+			continue
+		}
+		if directory, _, nested := strings.Cut(remainder, "/"); nested {
+			remainder = directory + "/"
+		}
+		if !slices.Contains(keys, remainder) {
+			keys = append(keys, remainder)
+		}
+	}
+	if len(keys) == 0 {
+		fakeError(writer, http.StatusNotFound)
+		return
+	}
+	slices.Sort(keys)
+
+	fakeSecret(writer, map[string]any{"keys": keys}, "", 0)
+}
+
+// generic logical storage for all other paths
+func (fake *FakeVault) logicalSecret(writer http.ResponseWriter, method string, path string, body map[string]any) {
+	switch method {
+	case http.MethodGet:
+		data, ok := fake.logical[path]
+		if !ok {
+			fakeError(writer, http.StatusNotFound)
+			return
+		}
+		fakeSecret(writer, data, "", 0)
+	case http.MethodPost, http.MethodPut:
+		fake.logical[path] = body
+		writer.WriteHeader(http.StatusNoContent)
+	default:
+		fakeError(writer, http.StatusMethodNotAllowed, "unsupported operation")
+	}
+}
+
+// response helpers
+func fakeJSON(writer http.ResponseWriter, status int, body map[string]any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(body)
+}
+
+func fakeSecret(writer http.ResponseWriter, data map[string]any, leaseID string, leaseDuration int) {
+	fakeJSON(writer, http.StatusOK, map[string]any{
+		"request_id":     fakeUUID(),
+		"lease_id":       leaseID,
+		"lease_duration": leaseDuration,
+		"renewable":      leaseDuration > 0,
+		"data":           data,
+	})
+}
+
+func fakeError(writer http.ResponseWriter, status int, messages ...string) {
+	if messages == nil {
+		messages = []string{}
+	}
+	fakeJSON(writer, status, map[string]any{"errors": messages})
+}
+
+// random uuid for identifiers
+func fakeUUID() string {
+	uuid := make([]byte, 16)
+	rand.Read(uuid)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
@@ -0,0 +1,60 @@
+package util
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// test fake vault server
+func TestFakeVault(test *testing.T) {
+	fake := NewFakeVault()
+	defer fake.Close()
+
+	client, _ := vault.NewClient(&vault.Config{Address: fake.URL})
+
+	// unauthenticated endpoints
+	if sealStatus, err := client.Sys().SealStatus(); err != nil || sealStatus.Sealed {
+		test.Errorf("fake seal status returned unexpected value: %v, error: %v", sealStatus, err)
+	}
+
+	// transient failures
+	fake.FailRequests(1, http.StatusServiceUnavailable)
+	if _, err := client.Sys().SealStatus(); err == nil || !strings.Contains(err.Error(), "transient failure") {
+		test.Errorf("expected error: transient failure, actual: %v", err)
+	}
+	if _, err := client.Sys().SealStatus(); err != nil {
+		test.Errorf("fake did not recover after transient failures: %v", err)
+	}
+
+	// token validation
+	client.SetToken("invalid")
+	if _, err := client.KVv2(KV2Mount).Get(context.Background(), KVPath); err == nil || !strings.Contains(err.Error(), "permission denied") {
+		test.Errorf("expected error: permission denied, actual: %v", err)
+	}
+	client.SetToken(VaultToken)
+
+	// kv2 versions
+	if _, err := client.KVv2(KV2Mount).Patch(context.Background(), KVPath, map[string]any{"new": "value"}); err != nil {
+		test.Error("fake kv2 patch failed")
+		test.Error(err)
+	}
+	kvSecret, err := client.KVv2(KV2Mount).Get(context.Background(), KVPath)
+	if err != nil || kvSecret.VersionMetadata.Version != 2 || kvSecret.Data["new"] != "value" || kvSecret.Data[KVKey] != KVValue {
+		test.Errorf("fake kv2 get returned unexpected value: %v, error: %v", kvSecret, err)
+	}
+	if metadata, err := client.KVv2(KV2Mount).GetMetadata(context.Background(), KVPath); err != nil || metadata.CurrentVersion != 2 || len(metadata.Versions) != 2 {
+		test.Errorf("fake kv2 metadata returned unexpected value: %v, error: %v", metadata, err)
+	}
+
+	// approle login
+	roleID, _ := client.Logical().Read("auth/approle/role/myAppRole/role-id")
+	secretID, _ := client.Logical().Write("auth/approle/role/myAppRole/secret-id", nil)
+	authSecret, err := client.Logical().Write("auth/approle/login", map[string]any{"role_id": roleID.Data["role_id"], "secret_id": secretID.Data["secret_id"]})
+	if err != nil || len(authSecret.Auth.ClientToken) == 0 {
+		test.Errorf("fake approle login returned unexpected value: %v, error: %v", authSecret, err)
+	}
+}
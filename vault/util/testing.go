@@ -1,24 +1,53 @@
 package util
 
-import vault "github.com/hashicorp/vault/api"
+import (
+	"log/slog"
+	"os"
+	"strings"
+
+	vault "github.com/hashicorp/vault/api"
+)
 
 // global test helpers
 const (
-	VaultAddress = "http://127.0.0.1:8200"
-	VaultToken   = "abcdefghijklmnopqrstuvwxyz09"
-	KVPath       = "foo/bar"
-	KVKey        = "password"
-	KVValue      = "supersecret"
-	KV1Mount     = "kv"
-	KV2Mount     = "secret"
+	VaultToken     = "abcdefghijklmnopqrstuvwxyz09"
+	KVPath         = "foo/bar"
+	KVKey          = "password"
+	KVValue        = "supersecret"
+	KV1Mount       = "kv"
+	KV2Mount       = "secret"
+	PasswordPolicy = "mypolicy"
+	// vault address in cmd fixtures
+	FixtureAddress = "http://localhost:8200"
 )
 
 var (
-	VaultClient = basicVaultClient()
-	RoleID = ""
-	SecretID = ""
+	Fake         = fakeVault()
+	VaultAddress = vaultAddress()
+	VaultClient  = basicVaultClient()
+	RoleID       = ""
+	SecretID     = ""
 )
 
+// helper for in-process fake vault server unless a live vault server address is specified in the environment
+func fakeVault() *FakeVault {
+	if len(os.Getenv("VAULT_TEST_ADDR")) > 0 {
+		return nil
+	}
+
+	return NewFakeVault()
+}
+
+// helper for live vault server address from environment, or otherwise in-process fake vault server address
+func vaultAddress() string {
+	if Fake == nil {
+		slog.Info("testing with live Vault server", "address", os.Getenv("VAULT_TEST_ADDR"))
+		return os.Getenv("VAULT_TEST_ADDR")
+	}
+
+	return Fake.URL
+}
+
 // helper for basic vault client
 func basicVaultClient() *vault.Client {
 	vaultConfig := &vault.Config{Address: VaultAddress}
@@ -28,3 +57,26 @@ func basicVaultClient() *vault.Client {
 
 	return client
 }
+
+// helper for cmd fixture file with vault address substituted for the testing vault server address
+func FixtureFile(path string) (*os.File, error) {
+	fixture, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// temporary file is unlinked immediately and removed when closed
+	file, err := os.CreateTemp("", "fixture-*.json")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(file.Name())
+	if _, err = file.WriteString(strings.ReplaceAll(string(fixture), FixtureAddress, VaultAddress)); err != nil {
+		return nil, err
+	}
+	if _, err = file.Seek(0, 0); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
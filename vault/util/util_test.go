@@ -25,6 +25,11 @@ func TestBootstrap(test *testing.T) {
 	VaultClient.Sys().EnableAuthWithOptions("aws", &vault.EnableAuthOptions{Type: "aws"})
 	VaultClient.Sys().EnableAuthWithOptions("kubernetes", &vault.EnableAuthOptions{Type: "kubernetes"})
 
+	// password policy for generated secrets
+	VaultClient.Logical().Write("sys/policies/password/"+PasswordPolicy, map[string]any{
+		"policy": "length = 20\nrule \"charset\" {\n  charset = \"abcdefghijklmnopqrstuvwxyz0123456789\"\n}\n",
+	})
+
 	// enable secrets: database, aws, kv1 (kv2 enabled by default with dev server)
 	VaultClient.Sys().Mount("aws/", &vault.MountInput{Type: "aws"})
 	VaultClient.Sys().Mount("database/", &vault.MountInput{Type: "database"})